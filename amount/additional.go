@@ -0,0 +1,125 @@
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/doswell/go8583"
+)
+
+//Amount types of additional amounts.
+const (
+	LedgerBalance    = "01"
+	AvailableBalance = "02"
+	AmountOwing      = "03"
+	AmountDue        = "04"
+	AmountCash       = "40" //Cash back
+	AmountGoods      = "41"
+)
+
+//additionalSize is the length of each DE54 entry: account type n2, amount type n2, currency n3, sign and amount n12.
+const additionalSize = 20
+
+//Additional is an entry of the additional amounts, DE54.
+type Additional struct {
+	AccountType string //e.g. 00 default, 10 savings, 20 cheque
+	AmountType  string //e.g. AvailableBalance
+	Amount      Amount //Negative for a debit (D) sign
+}
+
+//ParseAdditional reads the entries of an additional amounts value.
+func ParseAdditional(value string) ([]Additional, error) {
+	if len(value)%additionalSize != 0 {
+		return nil, errors.New(fmt.Sprint("Additional amounts length ", len(value), " is not a multiple of ", additionalSize))
+	}
+	entries := make([]Additional, 0, len(value)/additionalSize)
+	for offset := 0; offset < len(value); offset += additionalSize {
+		entry := value[offset : offset+additionalSize]
+		c, err := LookupCurrency(entry[4:7])
+		if err != nil {
+			return nil, err
+		}
+		a, err := parseSigned(entry[7:], c)
+		if err != nil {
+			return nil, errors.New(fmt.Sprint("Additional amount ", offset/additionalSize+1, ": ", err))
+		}
+		entries = append(entries, Additional{AccountType: entry[0:2], AmountType: entry[2:4], Amount: a})
+	}
+	return entries, nil
+}
+
+//FormatAdditional returns the additional amounts value of the entries.
+func FormatAdditional(entries []Additional) (string, error) {
+	buf := new(strings.Builder)
+	for _, entry := range entries {
+		if len(entry.AccountType) != 2 || len(entry.AmountType) != 2 {
+			return "", errors.New(fmt.Sprint("Invalid account type ", entry.AccountType, " or amount type ", entry.AmountType))
+		}
+		value, err := formatSigned(entry.Amount, Size)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(entry.AccountType)
+		buf.WriteString(entry.AmountType)
+		buf.WriteString(entry.Amount.Currency.Number)
+		buf.WriteString(value)
+	}
+	return buf.String(), nil
+}
+
+//GetAdditional returns the entries of DE54.
+func GetAdditional(msg go8583.Message) ([]Additional, error) {
+	value, ok := msg.GetField(54)
+	if !ok {
+		return nil, nil
+	}
+	return ParseAdditional(value)
+}
+
+//SetAdditional sets DE54 to the entries.
+func SetAdditional(msg go8583.Message, entries []Additional) error {
+	value, err := FormatAdditional(entries)
+	if err != nil {
+		return err
+	}
+	msg.SetString(54, value)
+	return nil
+}
+
+//FindAdditional returns the first entry of the amount type.
+func FindAdditional(entries []Additional, amountType string) (Additional, bool) {
+	for _, entry := range entries {
+		if entry.AmountType == amountType {
+			return entry, true
+		}
+	}
+	return Additional{}, false
+}
+
+//parseSigned reads a C or D sign followed by minor units, D being negative.
+func parseSigned(value string, c Currency) (Amount, error) {
+	if value == "" || (value[0] != 'C' && value[0] != 'D') {
+		return Amount{}, errors.New(fmt.Sprint("Invalid sign in amount ", value))
+	}
+	a, err := ParseMinor(value[1:], c)
+	if value[0] == 'D' {
+		a.Minor = -a.Minor
+	}
+	return a, err
+}
+
+//formatSigned returns the sign and size digits of minor units.
+func formatSigned(a Amount, size int) (string, error) {
+	if a.Minor == math.MinInt64 {
+		return "", errors.New(fmt.Sprint("Amount ", a, " exceeds ", size, " digits"))
+	}
+	sign := "C"
+	if a.Minor < 0 {
+		sign = "D"
+		a.Minor = -a.Minor
+	}
+	value, err := a.Format(size)
+	return sign + value, err
+}
@@ -0,0 +1,149 @@
+//Package amount handles the amounts of ISO 8583 messages, held as whole minor units of an ISO 4217 currency so the scale
+//of a field such as DE4 follows its currency field.
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/doswell/go8583"
+	"github.com/doswell/go8583/util"
+)
+
+//Size is the number of digits of the amount fields DE4 to DE6.
+const Size = 12
+
+//CurrencyFields maps each amount field to the field holding its currency code.
+var CurrencyFields = map[int]int{
+	4: 49, //Transaction
+	5: 50, //Settlement
+	6: 51, //Cardholder billing
+}
+
+//Amount is a number of minor units of a currency, e.g. cents. Minor is negative for a debit.
+type Amount struct {
+	Minor    int64
+	Currency Currency
+}
+
+//New creates an amount of minor units.
+func New(minor int64, c Currency) Amount {
+	return Amount{Minor: minor, Currency: c}
+}
+
+//ParseDecimal reads a decimal amount such as 12.34 or -5, which may have no more decimal places than the exponent of the
+//currency.
+func ParseDecimal(value string, c Currency) (Amount, error) {
+	digits := strings.TrimPrefix(value, "-")
+	negative := digits != value
+	whole, fraction := util.Split2(digits, ".")
+	if (whole == "" && fraction == "") || !util.IsDigits(whole) || !util.IsDigits(fraction) {
+		return Amount{}, errors.New(fmt.Sprint("Invalid amount ", value))
+	}
+	if len(fraction) > c.Exponent {
+		return Amount{}, errors.New(fmt.Sprint("Amount ", value, " has more than ", c.Exponent, " decimal places for ", c.Code))
+	}
+	minor, err := strconv.ParseInt(whole+util.RightPad2Len(fraction, "0", c.Exponent), 10, 64)
+	if err != nil {
+		return Amount{}, errors.New(fmt.Sprint("Invalid amount ", value, ": ", err))
+	}
+	if negative {
+		minor = -minor
+	}
+	return Amount{Minor: minor, Currency: c}, nil
+}
+
+//ParseMinor reads an unsigned field value of minor units, such as DE4.
+func ParseMinor(value string, c Currency) (Amount, error) {
+	if value == "" || !util.IsDigits(value) {
+		return Amount{}, errors.New(fmt.Sprint("Invalid amount ", value))
+	}
+	minor, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return Amount{}, errors.New(fmt.Sprint("Invalid amount ", value, ": ", err))
+	}
+	return Amount{Minor: minor, Currency: c}, nil
+}
+
+//Decimal returns the amount in major units, e.g. 12.34 for 1234 cents.
+func (a Amount) Decimal() string {
+	magnitude := uint64(a.Minor)
+	sign := ""
+	if a.Minor < 0 {
+		sign = "-"
+		magnitude = -magnitude //Wraps so math.MinInt64 has its magnitude
+	}
+	digits := strconv.FormatUint(magnitude, 10)
+	if len(digits) <= a.Currency.Exponent {
+		digits = util.LeftPad2Len(digits, "0", a.Currency.Exponent+1)
+	}
+	if a.Currency.Exponent == 0 {
+		return sign + digits
+	}
+	point := len(digits) - a.Currency.Exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (a Amount) String() string {
+	return a.Decimal() + " " + a.Currency.Code
+}
+
+//Format returns the minor units zero padded to size digits. The amount must not be negative.
+func (a Amount) Format(size int) (string, error) {
+	if a.Minor < 0 {
+		return "", errors.New(fmt.Sprint("Amount ", a, " is negative"))
+	}
+	value := strconv.FormatInt(a.Minor, 10)
+	if len(value) > size {
+		return "", errors.New(fmt.Sprint("Amount ", a, " exceeds ", size, " digits"))
+	}
+	return util.LeftPad2Len(value, "0", size), nil
+}
+
+//Get returns an amount field, such as DE4, in the currency of its currency field.
+func Get(msg go8583.Message, fieldNr int) (Amount, bool, error) {
+	value, ok := msg.GetField(fieldNr)
+	if !ok {
+		return Amount{}, false, nil
+	}
+	c, err := currencyOf(msg, fieldNr)
+	if err != nil {
+		return Amount{}, true, err
+	}
+	a, err := ParseMinor(value, c)
+	return a, true, err
+}
+
+//Set sets an amount field, such as DE4, and its currency field.
+func Set(msg go8583.Message, fieldNr int, a Amount) error {
+	currencyFieldNr, ok := CurrencyFields[fieldNr]
+	if !ok {
+		return errors.New(fmt.Sprint("Field ", fieldNr, " has no currency field"))
+	}
+	value, err := a.Format(Size)
+	if err != nil {
+		return err
+	}
+	msg.SetString(fieldNr, value)
+	msg.SetString(currencyFieldNr, a.Currency.Number)
+	return nil
+}
+
+//currencyOf looks up the currency of an amount field.
+func currencyOf(msg go8583.Message, fieldNr int) (Currency, error) {
+	currencyFieldNr, ok := CurrencyFields[fieldNr]
+	if !ok {
+		return Currency{}, errors.New(fmt.Sprint("Field ", fieldNr, " has no currency field"))
+	}
+	return messageCurrency(msg, currencyFieldNr)
+}
+
+func messageCurrency(msg go8583.Message, currencyFieldNr int) (Currency, error) {
+	code, ok := msg.GetField(currencyFieldNr)
+	if !ok {
+		return Currency{}, errors.New(fmt.Sprint("Currency field ", currencyFieldNr, " is not set"))
+	}
+	return LookupCurrency(code)
+}
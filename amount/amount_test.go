@@ -0,0 +1,201 @@
+package amount
+
+import (
+	"math"
+	"testing"
+)
+
+func mustCurrency(t *testing.T, code string) Currency {
+	t.Helper()
+	c, err := LookupCurrency(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAmountExponents(t *testing.T) {
+	tests := []struct {
+		currency string
+		decimal  string
+		minor    int64
+		field    string
+	}{
+		{"JPY", "1234", 1234, "000000001234"},
+		{"USD", "12.34", 1234, "000000001234"},
+		{"USD", "0.05", 5, "000000000005"},
+		{"BHD", "1.005", 1005, "000000001005"},
+		{"BHD", "0.000", 0, "000000000000"},
+		{"CLF", "0.0001", 1, "000000000001"},
+		{"CLF", "98765432.1234", 987654321234, "987654321234"},
+	}
+	for _, test := range tests {
+		c := mustCurrency(t, test.currency)
+		a, err := ParseDecimal(test.decimal, c)
+		if err != nil || a.Minor != test.minor {
+			t.Errorf("%s %s parsed as %d %v, want %d", test.decimal, test.currency, a.Minor, err, test.minor)
+			continue
+		}
+		if decimal := a.Decimal(); decimal != test.decimal {
+			t.Errorf("%d %s is %s, want %s", test.minor, test.currency, decimal, test.decimal)
+		}
+		field, err := a.Format(Size)
+		if err != nil || field != test.field {
+			t.Errorf("%s formatted as %s %v, want %s", a, field, err, test.field)
+		}
+		if b, err := ParseMinor(field, c); err != nil || b != a {
+			t.Errorf("%s read back as %s %v", field, b, err)
+		}
+	}
+	invalid := map[string]string{"JPY": "1.5", "USD": "1.234", "BHD": "1.0005", "CLF": "1.00001"}
+	for code, decimal := range invalid {
+		if _, err := ParseDecimal(decimal, mustCurrency(t, code)); err == nil {
+			t.Errorf("parsed %s %s with too many decimal places", decimal, code)
+		}
+	}
+}
+
+func TestAmountNegative(t *testing.T) {
+	usd := mustCurrency(t, "USD")
+	a, err := ParseDecimal("-5", usd)
+	if err != nil || a.Minor != -500 || a.Decimal() != "-5.00" {
+		t.Fatalf("-5 USD parsed as %d %v", a.Minor, err)
+	}
+	if _, err := a.Format(Size); err == nil {
+		t.Error("formatted a negative amount")
+	}
+	min := New(math.MinInt64, usd)
+	if decimal := min.Decimal(); decimal != "-92233720368547758.08" {
+		t.Errorf("math.MinInt64 cents is %s", decimal)
+	}
+	if _, err := min.Format(Size); err == nil {
+		t.Error("formatted math.MinInt64")
+	}
+	if _, err := FormatAdditional([]Additional{{"00", LedgerBalance, min}}); err == nil {
+		t.Error("formatted an additional amount of math.MinInt64")
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		decimal string
+		field   string
+	}{
+		{"1.234567", "61234567"},
+		{"1.0000000", "61000000"},
+		{"1.00000000", "61000000"},
+		{"0.00012345", "80012345"},
+		{"0.0000000010", "90000001"},
+		{"1234567", "01234567"},
+		{"150.5", "10001505"},
+	}
+	for _, test := range tests {
+		r, err := ParseRateDecimal(test.decimal)
+		if err != nil {
+			t.Errorf("%s: %v", test.decimal, err)
+			continue
+		}
+		field, err := r.Format()
+		if err != nil || field != test.field {
+			t.Errorf("%s formatted as %s %v, want %s", test.decimal, field, err, test.field)
+		}
+		if back, err := ParseRate(test.field); err != nil || back != r {
+			t.Errorf("%s read back as %v %v, want %v", test.field, back, err, r)
+		}
+	}
+	for _, decimal := range []string{"12345678", "1.2345678", "0.0000000001", "", ".", "1.2.3", "-1"} {
+		if r, err := ParseRateDecimal(decimal); err == nil {
+			t.Errorf("parsed invalid rate %q as %v", decimal, r)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		from, amount string
+		rate         Rate
+		to, want     string
+	}{
+		{"JPY", "1000", Rate{67, 4}, "USD", "6.70"},
+		{"USD", "12.35", Rate{376, 3}, "BHD", "4.644"},     //4.6436 rounds up
+		{"BHD", "1.000", Rate{712345, 7}, "CLF", "0.0712"}, //0.0712345 rounds down
+		{"CLF", "1.2345", Rate{3612, 2}, "USD", "44.59"},
+		{"USD", "1.01", Rate{15, 1}, "JPY", "2"},   //1.515 rounds up
+		{"USD", "-1.00", Rate{15, 1}, "JPY", "-2"}, //Half away from zero
+		{"USD", "1.00", Rate{5, 1}, "JPY", "1"},
+	}
+	for _, test := range tests {
+		a, err := ParseDecimal(test.amount, mustCurrency(t, test.from))
+		if err != nil {
+			t.Fatal(err)
+		}
+		converted, err := test.rate.Convert(a, mustCurrency(t, test.to))
+		if err != nil || converted.Decimal() != test.want {
+			t.Errorf("%s at %s is %s %v, want %s", a, test.rate, converted, err, test.want)
+		}
+	}
+	overflow := New(math.MaxInt64, mustCurrency(t, "JPY"))
+	if _, err := (Rate{2, 0}).Convert(overflow, mustCurrency(t, "JPY")); err == nil {
+		t.Error("converted an overflowing amount")
+	}
+}
+
+func TestAdditionalRoundTrip(t *testing.T) {
+	value := "0002840C000000001234" + "1001392D000000000500" + "2002048C000000001005" + "0004990D000000000001"
+	entries, err := ParseAdditional(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		accountType, amountType, decimal, code string
+	}{
+		{"00", AvailableBalance, "12.34", "USD"},
+		{"10", LedgerBalance, "-500", "JPY"},
+		{"20", AvailableBalance, "1.005", "BHD"},
+		{"00", AmountDue, "-0.0001", "CLF"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("read %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.AccountType != want[i].accountType || entry.AmountType != want[i].amountType ||
+			entry.Amount.Decimal() != want[i].decimal || entry.Amount.Currency.Code != want[i].code {
+			t.Errorf("entry %d is %+v, want %+v", i, entry, want[i])
+		}
+	}
+	if formatted, err := FormatAdditional(entries); err != nil || formatted != value {
+		t.Errorf("formatted as %s %v, want %s", formatted, err, value)
+	}
+	if _, err := ParseAdditional(value[:19]); err == nil {
+		t.Error("parsed a short entry")
+	}
+	if _, err := ParseAdditional("0002840X000000001234"); err == nil {
+		t.Error("parsed an entry with an invalid sign")
+	}
+}
+
+func TestReplacementRoundTrip(t *testing.T) {
+	usd, bhd := mustCurrency(t, "USD"), mustCurrency(t, "BHD")
+	value := "000000001000" + "000000002500" + "C00000050" + "D00000125"
+	r, err := ParseReplacement(value, usd, bhd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{r.Transaction.String(), r.Settlement.String(), r.TransactionFee.String(), r.SettlementFee.String()}
+	want := []string{"10.00 USD", "2.500 BHD", "0.50 USD", "-0.125 BHD"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("replacement amount %d is %s, want %s", i, got[i], want[i])
+		}
+	}
+	if formatted, err := r.Format(); err != nil || formatted != value {
+		t.Errorf("formatted as %s %v, want %s", formatted, err, value)
+	}
+	r.TransactionFee = New(-123456789, usd)
+	if _, err := r.Format(); err == nil {
+		t.Error("formatted a fee of more than 8 digits")
+	}
+	if _, err := ParseReplacement(value[:41], usd, bhd); err == nil {
+		t.Error("parsed a short value")
+	}
+}
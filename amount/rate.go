@@ -0,0 +1,130 @@
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/doswell/go8583"
+	"github.com/doswell/go8583/util"
+)
+
+//RateFields maps each conversion rate field to the amount field it converts DE4 into.
+var RateFields = map[int]int{
+	9:  5, //Settlement
+	10: 6, //Cardholder billing
+}
+
+//Rate is a conversion rate, DE9 or DE10: the leftmost digit is the number of decimal places of the seven digits which
+//follow, e.g. 61234567 is 1.234567.
+type Rate struct {
+	Value    int64 //At most 7 digits
+	Decimals int   //0 to 9
+}
+
+//ParseRate reads an eight digit conversion rate.
+func ParseRate(value string) (Rate, error) {
+	if len(value) != 8 || !util.IsDigits(value) {
+		return Rate{}, errors.New(fmt.Sprint("Invalid conversion rate ", value))
+	}
+	rateValue, _ := strconv.ParseInt(value[1:], 10, 64)
+	return Rate{Value: rateValue, Decimals: int(value[0] - '0')}, nil
+}
+
+//ParseRateDecimal reads a rate such as 1.234567, which may have at most 7 significant digits and 9 decimal places.
+//Trailing zeros of the fraction are dropped as needed, so 1.0000000 is 61000000.
+func ParseRateDecimal(value string) (Rate, error) {
+	whole, fraction := util.Split2(value, ".")
+	digits := strings.TrimLeft(whole+fraction, "0")
+	for (len(digits) > 7 || len(fraction) > 9) && strings.HasSuffix(fraction, "0") {
+		fraction = fraction[:len(fraction)-1]
+		digits = strings.TrimLeft(whole+fraction, "0")
+	}
+	if (whole == "" && fraction == "") || !util.IsDigits(whole) || !util.IsDigits(fraction) || len(digits) > 7 || len(fraction) > 9 {
+		return Rate{}, errors.New(fmt.Sprint("Invalid conversion rate ", value))
+	}
+	rateValue, _ := strconv.ParseInt("0"+digits, 10, 64)
+	return Rate{Value: rateValue, Decimals: len(fraction)}, nil
+}
+
+//Format returns the eight digit field value.
+func (r Rate) Format() (string, error) {
+	if r.Value < 0 || r.Value > 9999999 || r.Decimals < 0 || r.Decimals > 9 {
+		return "", errors.New(fmt.Sprint("Conversion rate ", r, " cannot be formatted"))
+	}
+	return strconv.Itoa(r.Decimals) + util.LeftPad2Len(strconv.FormatInt(r.Value, 10), "0", 7), nil
+}
+
+func (r Rate) String() string {
+	return New(r.Value, Currency{Exponent: r.Decimals}).Decimal()
+}
+
+//Convert converts an amount to another currency at the rate, rounding half away from zero.
+func (r Rate) Convert(a Amount, to Currency) (Amount, error) {
+	numerator := new(big.Int).Mul(big.NewInt(a.Minor), big.NewInt(r.Value))
+	numerator.Mul(numerator, pow10(to.Exponent))
+	denominator := new(big.Int).Mul(pow10(r.Decimals), pow10(a.Currency.Exponent))
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Mul(remainder.Abs(remainder), big.NewInt(2)).Cmp(denominator) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+	}
+	if !quotient.IsInt64() || quotient.Int64() == math.MinInt64 {
+		return Amount{}, errors.New(fmt.Sprint("Conversion of ", a, " at ", r, " overflows"))
+	}
+	return Amount{Minor: quotient.Int64(), Currency: to}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+//GetRate returns a conversion rate field, DE9 or DE10.
+func GetRate(msg go8583.Message, fieldNr int) (Rate, bool, error) {
+	value, ok := msg.GetField(fieldNr)
+	if !ok {
+		return Rate{}, false, nil
+	}
+	r, err := ParseRate(value)
+	return r, true, err
+}
+
+//SetRate sets a conversion rate field, DE9 or DE10.
+func SetRate(msg go8583.Message, fieldNr int, r Rate) error {
+	value, err := r.Format()
+	if err != nil {
+		return err
+	}
+	msg.SetString(fieldNr, value)
+	return nil
+}
+
+//Convert converts the transaction amount, DE4, at the rate of DE9 or DE10 into the currency of the amount field the
+//rate applies to, which must be set.
+func Convert(msg go8583.Message, rateFieldNr int) (Amount, error) {
+	amountFieldNr, ok := RateFields[rateFieldNr]
+	if !ok {
+		return Amount{}, errors.New(fmt.Sprint("Field ", rateFieldNr, " is not a conversion rate"))
+	}
+	r, ok, err := GetRate(msg, rateFieldNr)
+	if err != nil {
+		return Amount{}, err
+	}
+	if !ok {
+		return Amount{}, errors.New(fmt.Sprint("Conversion rate field ", rateFieldNr, " is not set"))
+	}
+	transaction, ok, err := Get(msg, 4)
+	if err != nil {
+		return Amount{}, err
+	}
+	if !ok {
+		return Amount{}, errors.New("Transaction amount field 4 is not set")
+	}
+	to, err := currencyOf(msg, amountFieldNr)
+	if err != nil {
+		return Amount{}, err
+	}
+	return r.Convert(transaction, to)
+}
@@ -0,0 +1,94 @@
+package amount
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583"
+)
+
+//replacementSize is the length of DE95: the actual transaction and settlement amounts n12, then the actual transaction
+//and settlement fees, each a sign and n8.
+const replacementSize = 42
+
+//Replacement holds the replacement amounts, DE95, of a partial reversal.
+type Replacement struct {
+	Transaction    Amount
+	Settlement     Amount
+	TransactionFee Amount //Negative for a debit (D) sign
+	SettlementFee  Amount //Negative for a debit (D) sign
+}
+
+//ParseReplacement reads a replacement amounts value. The transaction amount and fee are in the transaction currency and
+//the settlement amount and fee in the settlement currency.
+func ParseReplacement(value string, transaction, settlement Currency) (Replacement, error) {
+	var r Replacement
+	if len(value) != replacementSize {
+		return r, errors.New(fmt.Sprint("Replacement amounts length ", len(value), " is not ", replacementSize))
+	}
+	var err error
+	if r.Transaction, err = ParseMinor(value[0:12], transaction); err != nil {
+		return r, err
+	}
+	if r.Settlement, err = ParseMinor(value[12:24], settlement); err != nil {
+		return r, err
+	}
+	if r.TransactionFee, err = parseSigned(value[24:33], transaction); err != nil {
+		return r, err
+	}
+	if r.SettlementFee, err = parseSigned(value[33:42], settlement); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+//Format returns the replacement amounts value.
+func (r Replacement) Format() (string, error) {
+	transaction, err := r.Transaction.Format(Size)
+	if err != nil {
+		return "", err
+	}
+	settlement, err := r.Settlement.Format(Size)
+	if err != nil {
+		return "", err
+	}
+	transactionFee, err := formatSigned(r.TransactionFee, 8)
+	if err != nil {
+		return "", err
+	}
+	settlementFee, err := formatSigned(r.SettlementFee, 8)
+	if err != nil {
+		return "", err
+	}
+	return transaction + settlement + transactionFee + settlementFee, nil
+}
+
+//GetReplacement returns DE95 in the currencies of DE49 and DE50, or of DE49 alone if DE50 is not set.
+func GetReplacement(msg go8583.Message) (Replacement, bool, error) {
+	value, ok := msg.GetField(95)
+	if !ok {
+		return Replacement{}, false, nil
+	}
+	transaction, err := messageCurrency(msg, 49)
+	if err != nil {
+		return Replacement{}, true, err
+	}
+	settlement := transaction
+	if _, isSet := msg.GetField(50); isSet {
+		if settlement, err = messageCurrency(msg, 50); err != nil {
+			return Replacement{}, true, err
+		}
+	}
+	r, err := ParseReplacement(value, transaction, settlement)
+	return r, true, err
+}
+
+//SetReplacement sets DE95 to the replacement amounts.
+func SetReplacement(msg go8583.Message, r Replacement) error {
+	value, err := r.Format()
+	if err != nil {
+		return err
+	}
+	msg.SetString(95, value)
+	return nil
+}
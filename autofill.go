@@ -0,0 +1,259 @@
+package go8583
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/doswell/go8583/util"
+)
+
+//AutoFiller provides the value of a field which is not set when ApplyAutoFill is called, such as the STAN. An empty value
+//leaves the field unset, e.g. for message types which do not carry it.
+type AutoFiller interface {
+	AutoFill(msg Message, now time.Time) (string, error)
+}
+
+//SetAutoFill assigns the field from filler when ApplyAutoFill is called on a request without it. Auto fills cannot be set
+//once the template is frozen.
+func (t *BitmapMessageTemplate) SetAutoFill(fieldNr int, filler AutoFiller) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return errors.New(fmt.Sprint("Cannot set auto fill of field ", fieldNr, " on a frozen template"))
+	}
+	if t.AutoFill == nil {
+		t.AutoFill = make(map[int]AutoFiller)
+	}
+	t.AutoFill[fieldNr] = filler
+	return nil
+}
+
+//ApplyAutoFill sets every auto filled field which is not yet set. Call it once when building an originating message,
+//before it is packed. It does nothing for responses, whose function digit is odd, e.g. 0210, as they echo the values of
+//the request. Pack does not call it, so repacking a message, such as a SAF repeat or one being MACed, keeps its values.
+func (m *BitmapMessage) ApplyAutoFill() error {
+	if m.BitmapMessageTemplate == nil || m.GetMsgType()&0x0010 != 0 {
+		return nil
+	}
+	if !m.IsFrozen() {
+		m.lock.RLock()
+		defer m.lock.RUnlock()
+	}
+	if len(m.AutoFill) == 0 {
+		return nil
+	}
+	now := m.now()
+	for fieldNr, filler := range m.AutoFill {
+		if _, isSet := m.FieldValues[fieldNr]; isSet {
+			continue
+		}
+		value, err := filler.AutoFill(m, now)
+		if err != nil {
+			return errors.New(fmt.Sprint("Auto fill of field ", fieldNr, ": ", err))
+		}
+		if value != "" {
+			m.SetString(fieldNr, value)
+		}
+	}
+	return nil
+}
+
+//CounterStore persists counters so they continue from where they were after a restart.
+type CounterStore interface {
+	Load(name string) (value int64, err error)
+	Save(name string, value int64) error
+}
+
+//DefaultCounterBlock is the number of values a counter reserves with each save.
+const DefaultCounterBlock = 100
+
+//Counter counts from min to max and wraps back to min. It is safe for concurrent use. Rather than saving every value, the
+//counter saves the end of a block of values before handing out the first of them, so a restarted counter continues
+//after the block and never repeats a value it may have handed out. Values reserved but not used before a restart are
+//skipped.
+type Counter struct {
+	name     string
+	min, max int64
+	block    int64
+	value    int64
+	reserved int64 //The last value of the saved block
+	store    CounterStore
+	lock     sync.Mutex
+}
+
+//NewCounter creates a counter named name in store, continuing after its saved value, reserving DefaultCounterBlock
+//values at a time.
+func NewCounter(store CounterStore, name string, min, max int64) (*Counter, error) {
+	return NewBlockCounter(store, name, min, max, DefaultCounterBlock)
+}
+
+//NewBlockCounter creates a counter named name in store, continuing after its saved value, reserving block values at a
+//time. A block of 1 saves every value.
+func NewBlockCounter(store CounterStore, name string, min, max int64, block int64) (*Counter, error) {
+	if min > max || block < 1 {
+		return nil, errors.New(fmt.Sprint("Invalid counter ", name, " range ", min, " to ", max, " or block ", block))
+	}
+	value, err := store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Counter{name: name, min: min, max: max, block: block, value: value, reserved: value, store: store}, nil
+}
+
+//Next increments the counter and returns the new value, first saving a new block if the value is beyond the saved one.
+func (c *Counter) Next() (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	next := c.value + 1
+	wrapped := next < c.min || next > c.max
+	if wrapped {
+		next = c.min
+	}
+	if wrapped || next > c.reserved {
+		reserved := c.max
+		if c.max-next >= c.block {
+			reserved = next + c.block - 1
+		}
+		if err := c.store.Save(c.name, reserved); err != nil {
+			return 0, err
+		}
+		c.reserved = reserved
+	}
+	c.value = next
+	return next, nil
+}
+
+//StanGenerator assigns system trace audit numbers (DE11), 000001 to 999999, wrapping and skipping zero.
+type StanGenerator struct {
+	*Counter
+}
+
+//NewStanGenerator creates a STAN generator saving its counter in store.
+func NewStanGenerator(store CounterStore) (*StanGenerator, error) {
+	counter, err := NewCounter(store, "stan", 1, 999999)
+	if err != nil {
+		return nil, err
+	}
+	return &StanGenerator{counter}, nil
+}
+
+//NextStan returns the next STAN.
+func (g *StanGenerator) NextStan() (string, error) {
+	value, err := g.Next()
+	if err != nil {
+		return "", err
+	}
+	return util.LeftPad2Len(strconv.FormatInt(value, 10), "0", 6), nil
+}
+
+//AutoFill assigns the next STAN.
+func (g *StanGenerator) AutoFill(msg Message, now time.Time) (string, error) {
+	return g.NextStan()
+}
+
+//RrnGenerator assigns retrieval reference numbers (DE37), YDDDHHnnnnnn: the last digit of the year, the day of the year,
+//the hour and a sequence number.
+type RrnGenerator struct {
+	*Counter
+}
+
+//NewRrnGenerator creates an RRN generator saving its sequence in store.
+func NewRrnGenerator(store CounterStore) (*RrnGenerator, error) {
+	counter, err := NewCounter(store, "rrn", 1, 999999)
+	if err != nil {
+		return nil, err
+	}
+	return &RrnGenerator{counter}, nil
+}
+
+//NextRrn returns the next RRN for the time.
+func (g *RrnGenerator) NextRrn(now time.Time) (string, error) {
+	value, err := g.Next()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d%03d%02d%06d", now.Year()%10, now.YearDay(), now.Hour(), value), nil
+}
+
+//AutoFill assigns the next RRN for the time auto fill is applied.
+func (g *RrnGenerator) AutoFill(msg Message, now time.Time) (string, error) {
+	return g.NextRrn(now)
+}
+
+type memoryCounterStore struct {
+	values map[string]int64
+	lock   sync.Mutex
+}
+
+//NewMemoryCounterStore creates a counter store which does not survive a restart.
+func NewMemoryCounterStore() CounterStore {
+	return &memoryCounterStore{values: make(map[string]int64)}
+}
+
+func (s *memoryCounterStore) Load(name string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.values[name], nil
+}
+
+func (s *memoryCounterStore) Save(name string, value int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[name] = value
+	return nil
+}
+
+type fileCounterStore struct {
+	path string
+	lock sync.Mutex
+}
+
+//NewFileCounterStore creates a counter store keeping all counters in one JSON file, replaced and synced on every save.
+func NewFileCounterStore(path string) CounterStore {
+	return &fileCounterStore{path: path}
+}
+
+func (s *fileCounterStore) read() (map[string]int64, error) {
+	values := make(map[string]int64)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &values); err != nil {
+		return nil, errors.New(fmt.Sprint("Corrupt counter file ", s.path, ": ", err))
+	}
+	return values, nil
+}
+
+func (s *fileCounterStore) Load(name string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	values, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	return values[name], nil
+}
+
+func (s *fileCounterStore) Save(name string, value int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	values, err := s.read()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(s.path, data, 0600)
+}
@@ -0,0 +1,134 @@
+package go8583
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingStore struct {
+	CounterStore
+	saves []int64
+}
+
+func (s *countingStore) Save(name string, value int64) error {
+	s.saves = append(s.saves, value)
+	return s.CounterStore.Save(name, value)
+}
+
+func autoFillTemplate(t *testing.T) *BitmapMessageTemplate {
+	tmpl := &BitmapMessageTemplate{Fields: CreateFields(
+		NewDateTimeField(7, "transmissionDateTime", LayoutMMDDhhmmss, time.UTC),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+	)}
+	tmpl.Now = func() time.Time { return time.Date(2026, 10, 19, 12, 30, 45, 0, time.UTC) }
+	stan, err := NewStanGenerator(NewMemoryCounterStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SetAutoFill(11, stan)
+	tmpl.SetAutoFillTime(7)
+	return tmpl.Freeze()
+}
+
+func TestApplyAutoFill(t *testing.T) {
+	tmpl := autoFillTemplate(t)
+	request := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	request.Init()
+	request.SetMsgType(0x0200)
+	if _, err := request.Pack(); err != nil {
+		t.Fatal(err)
+	}
+	if request.Has("11") {
+		t.Error("Pack assigned a STAN")
+	}
+	if err := request.ApplyAutoFill(); err != nil {
+		t.Fatal(err)
+	}
+	if stan, _ := request.GetString(11); stan != "000001" {
+		t.Errorf("STAN is %s, want 000001", stan)
+	}
+	if dateTime, _ := request.GetString(7); dateTime != "1019123045" {
+		t.Errorf("DE7 is %s, want 1019123045", dateTime)
+	}
+	if err := request.ApplyAutoFill(); err != nil {
+		t.Fatal(err)
+	}
+	if stan, _ := request.GetString(11); stan != "000001" {
+		t.Errorf("STAN changed to %s when applied again", stan)
+	}
+
+	response := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	response.Init()
+	response.SetMsgType(0x0210)
+	if err := response.ApplyAutoFill(); err != nil {
+		t.Fatal(err)
+	}
+	if response.Has("11") || response.Has("7") {
+		t.Error("auto filled a response")
+	}
+
+	next := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	next.Init()
+	next.SetMsgType(0x0800)
+	next.ApplyAutoFill()
+	if stan, _ := next.GetString(11); stan != "000002" {
+		t.Errorf("next STAN is %s, want 000002", stan)
+	}
+}
+
+func TestCounterReservesBlocks(t *testing.T) {
+	store := &countingStore{CounterStore: NewMemoryCounterStore()}
+	counter, err := NewBlockCounter(store, "stan", 1, 25, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for want := int64(1); want <= 12; want++ {
+		if got, err := counter.Next(); err != nil || got != want {
+			t.Fatalf("got %d %v, want %d", got, err, want)
+		}
+	}
+	if len(store.saves) != 2 || store.saves[0] != 10 || store.saves[1] != 20 {
+		t.Errorf("saved %v, want the block ends 10 and 20", store.saves)
+	}
+
+	//A restart continues after the saved block.
+	restarted, err := NewBlockCounter(store, "stan", 1, 25, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []int64
+	for i := 0; i < 7; i++ {
+		value, _ := restarted.Next()
+		values = append(values, value)
+	}
+	want := []int64{21, 22, 23, 24, 25, 1, 2}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Fatalf("after restart got %v, want %v", values, want)
+		}
+	}
+	if saves := store.saves[2:]; len(saves) != 2 || saves[0] != 25 || saves[1] != 10 {
+		t.Errorf("saved %v after restart, want 25 at the top of the range and 10 after wrapping", saves)
+	}
+
+	if _, err = NewBlockCounter(store, "stan", 1, 25, 0); err == nil {
+		t.Error("created a counter with an empty block without error")
+	}
+}
+
+func TestFileCounterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	store := NewFileCounterStore(path)
+	if value, err := store.Load("stan"); err != nil || value != 0 {
+		t.Fatalf("loaded %d %v from a new store", value, err)
+	}
+	store.Save("stan", 100)
+	store.Save("rrn", 200)
+	reopened := NewFileCounterStore(path)
+	for name, want := range map[string]int64{"stan": 100, "rrn": 200} {
+		if value, err := reopened.Load(name); err != nil || value != want {
+			t.Errorf("loaded %s %d %v, want %d", name, value, err, want)
+		}
+	}
+}
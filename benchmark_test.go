@@ -0,0 +1,147 @@
+package go8583
+
+import (
+	"testing"
+)
+
+var benchmarkTemplate = (&BitmapMessageTemplate{
+	Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewFixedField(4, "amountTransaction", 12, Numeric),
+		NewFixedField(7, "transmissionDateTime", 10, Numeric),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewFixedField(12, "localTranTime", 6, Numeric),
+		NewFixedField(13, "localTranDate", 4, Numeric),
+		NewLlVarField(32, "acquiringInstId", 11, Numeric),
+		NewFixedField(37, "retrievalReferenceNumber", 12, AlphaNumericSpecial),
+		NewFixedField(41, "terminalId", 8, AlphaNumericSpecial),
+		NewFixedField(42, "cardAcceptorId", 15, AlphaNumericSpecial),
+		NewFixedField(49, "currencyCodeTran", 3, Numeric),
+		NewLlVarField(100, "receivingInstId", 11, Numeric),
+	),
+}).Freeze()
+
+var benchmarkValues = map[int]string{
+	2:   "4012345678909",
+	3:   "000000",
+	4:   "000000001000",
+	7:   "1019120000",
+	11:  "000001",
+	12:  "120000",
+	13:  "1019",
+	32:  "123456",
+	37:  "123456789012",
+	41:  "TERM0001",
+	42:  "MERCHANT0000001",
+	49:  "840",
+	100: "654321",
+}
+
+func fillBenchmarkMessage(msg *BitmapMessage) {
+	msg.SetMsgType(0x0200)
+	for fieldNr, value := range benchmarkValues {
+		msg.SetString(fieldNr, value)
+	}
+}
+
+func newBenchmarkMessage() *BitmapMessage {
+	msg := &BitmapMessage{BitmapMessageTemplate: benchmarkTemplate}
+	msg.Init()
+	return msg
+}
+
+func packedBenchmarkMessage(b *testing.B) []byte {
+	msg := newBenchmarkMessage()
+	fillBenchmarkMessage(msg)
+	data, err := msg.Pack()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkPack(b *testing.B) {
+	msg := newBenchmarkMessage()
+	fillBenchmarkMessage(msg)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := msg.Pack(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendPack(b *testing.B) {
+	msg := newBenchmarkMessage()
+	fillBenchmarkMessage(msg)
+	buf := make([]byte, 0, 512)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = msg.AppendPack(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpack(b *testing.B) {
+	data := packedBenchmarkMessage(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := BitmapUnpack(data, benchmarkTemplate, newBenchmarkMessage()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpackPooled(b *testing.B) {
+	data := packedBenchmarkMessage(b)
+	pool := NewMessagePool(benchmarkTemplate)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg := pool.Get()
+		if err := BitmapUnpack(data, benchmarkTemplate, msg); err != nil {
+			b.Fatal(err)
+		}
+		pool.Put(msg)
+	}
+}
+
+func BenchmarkBuildAndPackPooled(b *testing.B) {
+	pool := NewMessagePool(benchmarkTemplate)
+	buf := make([]byte, 0, 512)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg := pool.Get()
+		fillBenchmarkMessage(msg)
+		var err error
+		if buf, err = msg.AppendPack(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+		pool.Put(msg)
+	}
+}
+
+//BenchmarkParallelPackUnpack shares the frozen template between goroutines, each with messages of its own.
+func BenchmarkParallelPackUnpack(b *testing.B) {
+	pool := NewMessagePool(benchmarkTemplate)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		buf := make([]byte, 0, 512)
+		for pb.Next() {
+			msg := pool.Get()
+			fillBenchmarkMessage(msg)
+			buf, _ = msg.AppendPack(buf[:0])
+			pool.Put(msg)
+			msg = pool.Get()
+			BitmapUnpack(buf, benchmarkTemplate, msg)
+			pool.Put(msg)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package go8583
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//NewFixedBinaryField creates a binary field whose size is given in bits, as in "b 64" for DE52 or DE64.
+func NewFixedBinaryField(bitNumber int, name string, bits int) Field {
+	return NewFixedField(bitNumber, name, (bits+7)/8, Binary)
+}
+
+//NewFixedHexField creates a binary field of size bytes carried on the wire as hex characters, for text based protocols.
+func NewFixedHexField(bitNumber int, name string, size int) Field {
+	return &BitmapMessageField{bitNumber, name, Binary, Fixed, size, NewHexPackerUnpacker(NewFixedFieldPackerUnpacker(size * 2)), nil, nil}
+}
+
+//NewVariableHexField creates a variable length binary field carried on the wire as hex characters. The length prefix of lengthDigits
+//counts hex characters.
+func NewVariableHexField(bitNumber int, name string, size int, lengthDigits int) Field {
+	return &BitmapMessageField{bitNumber, name, Binary, variableFieldLength(lengthDigits), size, NewHexPackerUnpacker(NewVariableFieldPackerUnpacker(lengthDigits)), nil, nil}
+}
+
+type hexField struct {
+	packerUnpacker PackerUnpacker
+}
+
+//NewHexPackerUnpacker wraps a packer so that binary field data is carried as upper case hex characters.
+func NewHexPackerUnpacker(packerUnpacker PackerUnpacker) PackerUnpacker {
+	return &hexField{packerUnpacker}
+}
+
+func (f *hexField) Unpack(offset int, data []byte) (newOffset int, fieldData []byte, err error) {
+	newOffset, hexData, err := f.packerUnpacker.Unpack(offset, data)
+	if err != nil {
+		return newOffset, nil, err
+	}
+	fieldData = make([]byte, hex.DecodedLen(len(hexData)))
+	if _, err = hex.Decode(fieldData, hexData); err != nil {
+		return newOffset, nil, errors.New(fmt.Sprint("Invalid hex data: ", err))
+	}
+	return newOffset, fieldData, nil
+}
+
+func (f *hexField) Pack(fieldData []byte) (data []byte, err error) {
+	return f.packerUnpacker.Pack([]byte(strings.ToUpper(hex.EncodeToString(fieldData))))
+}
+
+func (f *hexField) ReadFrame(r io.Reader) (frame []byte, err error) {
+	frameReader, ok := f.packerUnpacker.(FrameReader)
+	if !ok {
+		return nil, errors.New("Field cannot be read from a stream")
+	}
+	return frameReader.ReadFrame(r)
+}
+
+//GetBytes returns the value of a field as bytes, for binary fields such as DE52.
+func (m *BitmapMessage) GetBytes(fieldNr int) (value []byte, isSet bool) {
+	fieldValue, isSet := m.FieldValues[fieldNr]
+	if !isSet {
+		return nil, false
+	}
+	return []byte(fieldValue.Value), true
+}
+
+//SetBytes sets the value of a binary field.
+func (m *BitmapMessage) SetBytes(fieldNr int, value []byte) {
+	m.SetString(fieldNr, string(value))
+}
+
+//GetHex returns the value of a binary field as upper case hex.
+func (m *BitmapMessage) GetHex(fieldNr int) (value string, isSet bool) {
+	fieldValue, isSet := m.FieldValues[fieldNr]
+	if !isSet {
+		return "", false
+	}
+	return strings.ToUpper(hex.EncodeToString([]byte(fieldValue.Value))), true
+}
+
+//SetHex sets the value of a binary field from hex.
+func (m *BitmapMessage) SetHex(fieldNr int, value string) error {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return errors.New(fmt.Sprint("Invalid hex for field ", fieldNr, ": ", err))
+	}
+	m.SetBytes(fieldNr, data)
+	return nil
+}
+
+//displayValue formats binary values as hex for String() output and JSON.
+func displayValue(field Field, value FieldValue) string {
+	if field != nil && field.GetType() == Binary {
+		return strings.ToUpper(hex.EncodeToString([]byte(value.Value)))
+	}
+	return value.Value
+}
@@ -0,0 +1,82 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func binaryTestMessage() *BitmapMessage {
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewFixedHexField(52, "pinData", 8),
+		NewFixedBinaryField(64, "mac", 64),
+		NewVariableHexField(96, "securityData", 99, 2),
+	)}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	return msg
+}
+
+func TestHexFieldRoundTrip(t *testing.T) {
+	msg := binaryTestMessage()
+	field := msg.Fields[52]
+	if field.GetSize() != 8 || field.GetLength() != Fixed || field.GetType() != Binary {
+		t.Errorf("hex field is %v %v %v, want 8 bytes fixed binary", field.GetSize(), field.GetLength(), field.GetType())
+	}
+	data, err := field.PackField(FieldValue{Value: "\x01\x23\x45\x67\x89\xAB\xCD\xEF"})
+	if err != nil || string(data) != "0123456789ABCDEF" {
+		t.Fatalf("packed %q %v, want 0123456789ABCDEF", data, err)
+	}
+	if _, value, err := field.UnpackField(0, []byte("0123456789abcdef")); err != nil || value.Value != "\x01\x23\x45\x67\x89\xAB\xCD\xEF" {
+		t.Errorf("unpacked %X %v", value.Value, err)
+	}
+	if _, _, err := field.UnpackField(0, []byte("0123456789ABCDEG")); err == nil {
+		t.Error("unpacked invalid hex")
+	}
+	if _, _, err := field.UnpackField(0, []byte("0123")); err == nil {
+		t.Error("unpacked a short field")
+	}
+
+	variable := msg.Fields[96]
+	if variable.GetLength() != LlVar {
+		t.Errorf("variable hex field length is %s, want LlVar", variable.GetLength())
+	}
+	data, err = variable.PackField(FieldValue{Value: "\xAB\xCD\xEF"})
+	if err != nil || string(data) != "06ABCDEF" {
+		t.Errorf("packed %q %v, want 06ABCDEF", data, err)
+	}
+}
+
+func TestGetSetHex(t *testing.T) {
+	msg := binaryTestMessage()
+	if _, ok := msg.GetHex(52); ok {
+		t.Error("field 52 is set")
+	}
+	if err := msg.SetHex(52, "0123456789abcdef"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := msg.GetHex(52); !ok || value != "0123456789ABCDEF" {
+		t.Errorf("got %s %v, want 0123456789ABCDEF", value, ok)
+	}
+	if value, _ := msg.GetBytes(52); string(value) != "\x01\x23\x45\x67\x89\xAB\xCD\xEF" {
+		t.Errorf("got bytes %X", value)
+	}
+	for _, invalid := range []string{"ABC", "0123456789ABCDEZ", "0x12"} {
+		if err := msg.SetHex(52, invalid); err == nil {
+			t.Errorf("set invalid hex %s", invalid)
+		}
+		if value, _ := msg.GetHex(52); value != "0123456789ABCDEF" {
+			t.Errorf("invalid hex %s changed the field to %s", invalid, value)
+		}
+	}
+	msg.SetBytes(64, []byte{0xDE, 0xAD, 0xBE, 0xEF, 0, 0, 0, 0})
+	if err := msg.SetHex(52, "0000000000000000"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0200" + "\x00\x00\x00\x00\x00\x00\x10\x01" + "0000000000000000" + "\xDE\xAD\xBE\xEF\x00\x00\x00\x00"; string(data) != want {
+		t.Errorf("packed %q, want %q", data, want)
+	}
+}
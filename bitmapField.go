@@ -1,9 +1,10 @@
 package go8583
 
 import (
-	"doswell/util"
 	"errors"
 	"fmt"
+
+	"github.com/doswell/go8583/util"
 )
 
 type bitmapField struct {
@@ -12,7 +13,7 @@ type bitmapField struct {
 }
 
 func NewBitmapField(fieldNumber int, name string, fieldPackerUnpacker PackerUnpacker, fields []Field) Field {
-	field := &bitmapField{&BitmapMessageField{fieldNumber, name, Binary, LlVar, 0, fieldPackerUnpacker, nil},
+	field := &bitmapField{&BitmapMessageField{fieldNumber, name, Binary, LlVar, 0, fieldPackerUnpacker, nil, nil},
 		new(BitmapMessageTemplate),
 	}
 	field.Fields = CreateFields(fields...)
@@ -22,41 +23,60 @@ func NewBitmapField(fieldNumber int, name string, fieldPackerUnpacker PackerUnpa
 
 func (f *bitmapField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
 	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value, err = f.unpackSubFields(fieldData)
+	return newOffset, value, err
+	//return offset, "", errors.New("Undefined unpacking")
+}
+func (f *bitmapField) PackField(value FieldValue) (data []byte, err error) {
+
+	bitmapBytes, err := f.packSubFields(value)
+	if err != nil {
+		return data, err
+	}
+
+	return f.Pack(bitmapBytes)
+}
+
+func (f *bitmapField) unpackSubFields(fieldData []byte) (value FieldValue, err error) {
+	values := make(map[int]FieldValue)
+	if len(fieldData) < 8 {
+		return value, errors.New("Attempt to read passed end of data")
+	}
 	//Get the bitmap.
-	bitmapData := fieldData[0:8]
-	bitmap := util.GetBitmap(bitmapData)
+	bitmapSize := 8
+	if isBitmapSet(fieldData, 1) {
+		bitmapSize = 16
+		if len(fieldData) < bitmapSize {
+			return value, errors.New("Attempt to read passed end of data")
+		}
+	}
+	bitmap := util.GetBitmap(fieldData[0:bitmapSize])
 
-	bitmapFieldValue := new(FieldValue)
-	bitmapFieldValue.FieldValues = make(map[int]FieldValue)
-	fieldOffset := 8
+	fieldOffset := bitmapSize
 
 	for i, b := range bitmap {
 		i++
-		if b {
+		if b && i > 1 {
 			field, err := f.GetFieldDef(i)
 			if err != nil {
-				return newOffset, value, errors.New(fmt.Sprint("Undefined field ", i, " in template"))
+				return value, errors.New(fmt.Sprint("Undefined field ", i, " in template"))
 			}
 			var fValue FieldValue
 
 			fieldOffset, fValue, err = field.UnpackField(fieldOffset, fieldData)
 			if err != nil {
-				return newOffset, value, err
+				return value, err
 			}
 
-			bitmapFieldValue.FieldValues[i] = fValue
+			values[i] = fValue
 		}
 	}
-
-	return newOffset, *bitmapFieldValue, nil
-	//return offset, "", errors.New("Undefined unpacking")
+	return FieldValue{FieldValues: values}, nil
 }
-func (f *bitmapField) PackField(value FieldValue) (data []byte, err error) {
-
-	bitmapBytes, err := PackBitmapFields(value.FieldValues, f.BitmapMessageTemplate)
-	if err != nil {
-		return data, err
-	}
 
-	return f.Pack(bitmapBytes)
+func (f *bitmapField) packSubFields(value FieldValue) (data []byte, err error) {
+	return PackBitmapFields(value.FieldValues, f.BitmapMessageTemplate)
 }
@@ -0,0 +1,86 @@
+//Package card validates and inspects card numbers (PANs): Luhn check digits, BIN extraction, scheme detection and masking.
+package card
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/doswell/go8583/util"
+)
+
+const (
+	minPanLength = 12
+	maxPanLength = 19
+)
+
+//LuhnCheckDigit returns the Luhn (mod 10) check digit for number, which does not include a check digit.
+func LuhnCheckDigit(number string) (digit int, err error) {
+	if number == "" || !util.IsDigits(number) {
+		return 0, errors.New("Number must be digits")
+	}
+	return util.CheckDigit(number, 10)
+}
+
+//AppendCheckDigit returns number followed by its Luhn check digit.
+func AppendCheckDigit(number string) (string, error) {
+	digit, err := LuhnCheckDigit(number)
+	if err != nil {
+		return "", err
+	}
+	return number + strconv.Itoa(digit), nil
+}
+
+//LuhnValid returns true if the last digit of number is its Luhn check digit.
+func LuhnValid(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	digit, err := LuhnCheckDigit(number[:len(number)-1])
+	return err == nil && int(number[len(number)-1]-'0') == digit
+}
+
+//Validate checks that pan is 12 to 19 digits with a valid Luhn check digit.
+func Validate(pan string) error {
+	if len(pan) < minPanLength || len(pan) > maxPanLength || !util.IsDigits(pan) {
+		return errors.New(fmt.Sprint("PAN must be ", minPanLength, " to ", maxPanLength, " digits"))
+	}
+	if !LuhnValid(pan) {
+		return errors.New("PAN check digit is invalid")
+	}
+	return nil
+}
+
+//Bin returns the leading length digits of the PAN, the bank or issuer identification number. length is 6 or 8.
+func Bin(pan string, length int) (string, error) {
+	if length != 6 && length != 8 {
+		return "", errors.New("BIN length must be 6 or 8")
+	}
+	if len(pan) < length || !util.IsDigits(pan) {
+		return "", errors.New("PAN is too short or not digits")
+	}
+	return pan[:length], nil
+}
+
+//Mask replaces all but the first 6 and last 4 digits of the PAN with '*', as permitted for display by PCI DSS. Only the
+//last 4 digits are shown for PANs too short to show both.
+func Mask(pan string) string {
+	return MaskWith(pan, 6, 4, '*')
+}
+
+//MaskWith replaces all but the first and last digits of the PAN with maskChar. If the PAN is too short to show both, only
+//the last digits are shown.
+func MaskWith(pan string, first, last int, maskChar byte) string {
+	if last >= len(pan) {
+		return strings.Repeat(string(maskChar), len(pan))
+	}
+	if first+last >= len(pan) {
+		first = 0
+	}
+	masked := []byte(pan)
+	for i := first; i < len(pan)-last; i++ {
+		masked[i] = maskChar
+	}
+	return string(masked)
+}
@@ -0,0 +1,138 @@
+package card
+
+import (
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  bool
+	}{
+		{"79927398713", true},
+		{"79927398710", false},
+		{"4111111111111111", true},
+		{"4111111111111112", false},
+		{"00", true},
+		{"18", true},
+		{"0", false},
+		{"", false},
+		{"4111-1111", false},
+		{"4111111111111 11", false},
+	}
+	for _, test := range tests {
+		if valid := LuhnValid(test.number); valid != test.valid {
+			t.Errorf("LuhnValid(%q) is %v, want %v", test.number, valid, test.valid)
+		}
+	}
+	if number, err := AppendCheckDigit("7992739871"); err != nil || number != "79927398713" {
+		t.Errorf("AppendCheckDigit is %s %v, want 79927398713", number, err)
+	}
+	for _, number := range []string{"", "12a"} {
+		if _, err := LuhnCheckDigit(number); err == nil {
+			t.Errorf("computed a check digit for %q", number)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]bool{
+		"4222222222222":        true,
+		"4111111111111111":     true,
+		"6759649826438453":     true,
+		"000000000000":         true, //12 digits, the minimum
+		"4000000000000000006":  true, //19 digits, the maximum
+		"00000000000":          false,
+		"40000000000000000006": false,
+		"4111111111111112":     false,
+		"411111111111111A":     false,
+		"":                     false,
+	}
+	for pan, valid := range tests {
+		if err := Validate(pan); (err == nil) != valid {
+			t.Errorf("Validate(%q) is %v, want valid %v", pan, err, valid)
+		}
+	}
+}
+
+func TestBin(t *testing.T) {
+	tests := []struct {
+		pan    string
+		length int
+		bin    string
+		valid  bool
+	}{
+		{"4111111111111111", 6, "411111", true},
+		{"5555555555554444", 8, "55555555", true},
+		{"4111111", 8, "", false},
+		{"4111111111111111", 7, "", false},
+		{"41111X1111111111", 6, "", false},
+	}
+	for _, test := range tests {
+		bin, err := Bin(test.pan, test.length)
+		if (err == nil) != test.valid || bin != test.bin {
+			t.Errorf("Bin(%s, %d) is %q %v, want %q", test.pan, test.length, bin, err, test.bin)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := map[string]string{
+		"4111111111111111":    "411111******1111",
+		"378282246310005":     "378282*****0005",
+		"4000000000000000006": "400000*********0006",
+		"4111111111":          "******1111",
+		"1234":                "****",
+		"":                    "",
+	}
+	for pan, want := range tests {
+		if masked := Mask(pan); masked != want {
+			t.Errorf("Mask(%s) is %s, want %s", pan, masked, want)
+		}
+	}
+	if masked := MaskWith("4111111111111111", 0, 4, 'X'); masked != "XXXXXXXXXXXX1111" {
+		t.Errorf("MaskWith is %s", masked)
+	}
+}
+
+func TestDetectScheme(t *testing.T) {
+	tests := []struct {
+		pan    string
+		scheme Scheme
+	}{
+		{"4111111111111111", Visa},
+		{"4012888888881881", Visa},
+		{"4222222222222", Visa},
+		{"5555555555554444", Mastercard},
+		{"5105105105105100", Mastercard},
+		{"2223003122003222", Mastercard},
+		{"378282246310005", Amex},
+		{"371449635398431", Amex},
+		{"6011111111111117", Discover},
+		{"6440000000000005", Discover},
+		{"6500000000000002", Discover},
+		{"30569309025904", DinersClub},
+		{"38520000023237", DinersClub},
+		{"36227206271667", DinersClub},
+		{"3530111333300000", Jcb},
+		{"3566002020360505", Jcb},
+		{"6200000000000005", UnionPay},
+		{"6759649826438453", Maestro},
+		{"5018000000000009", Maestro},
+		{"6304000000000000", Maestro},
+		{"2200000000000004", Mir},
+		{"37828224631000", UnknownScheme},    //Amex prefix, wrong length
+		{"55555555555544441", UnknownScheme}, //Mastercard prefix, wrong length
+		{"9111111111111111", UnknownScheme},
+		{"4111X11111111111", UnknownScheme},
+		{"", UnknownScheme},
+	}
+	for _, test := range tests {
+		if scheme := DetectScheme(test.pan); scheme != test.scheme {
+			t.Errorf("DetectScheme(%s) is %s, want %s", test.pan, scheme, test.scheme)
+		}
+	}
+	if name := Amex.String(); name != "American Express" {
+		t.Errorf("Amex is named %s", name)
+	}
+}
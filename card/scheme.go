@@ -0,0 +1,86 @@
+package card
+
+import "github.com/doswell/go8583/util"
+
+//Scheme is a card brand, detected from the BIN.
+type Scheme int
+
+const (
+	UnknownScheme Scheme = iota
+	Visa
+	Mastercard
+	Amex
+	Discover
+	Jcb
+	DinersClub
+	UnionPay
+	Maestro
+	Mir
+)
+
+var schemeLookup = map[Scheme]string{
+	UnknownScheme: "Unknown",
+	Visa:          "Visa",
+	Mastercard:    "Mastercard",
+	Amex:          "American Express",
+	Discover:      "Discover",
+	Jcb:           "JCB",
+	DinersClub:    "Diners Club",
+	UnionPay:      "UnionPay",
+	Maestro:       "Maestro",
+	Mir:           "Mir",
+}
+
+func (s Scheme) String() string {
+	return schemeLookup[s]
+}
+
+//binRange is a range of PAN prefixes, low and high having the same number of digits.
+type binRange struct {
+	low, high string
+	scheme    Scheme
+	minLength int
+	maxLength int
+}
+
+//schemeRanges are checked in order, so narrower ranges come before the wider ranges they overlap.
+var schemeRanges = []binRange{
+	{"34", "34", Amex, 15, 15},
+	{"37", "37", Amex, 15, 15},
+	{"300", "305", DinersClub, 14, 19},
+	{"36", "36", DinersClub, 14, 19},
+	{"38", "39", DinersClub, 14, 19},
+	{"3528", "3589", Jcb, 16, 19},
+	{"4", "4", Visa, 13, 19},
+	{"2200", "2204", Mir, 16, 19},
+	{"2221", "2720", Mastercard, 16, 16},
+	{"51", "55", Mastercard, 16, 16},
+	{"5018", "5018", Maestro, 12, 19},
+	{"5020", "5020", Maestro, 12, 19},
+	{"5038", "5038", Maestro, 12, 19},
+	{"5893", "5893", Maestro, 12, 19},
+	{"6304", "6304", Maestro, 12, 19},
+	{"6759", "6759", Maestro, 12, 19},
+	{"6761", "6763", Maestro, 12, 19},
+	{"6011", "6011", Discover, 16, 19},
+	{"644", "649", Discover, 16, 19},
+	{"65", "65", Discover, 16, 19},
+	{"62", "62", UnionPay, 16, 19},
+}
+
+//DetectScheme returns the scheme of the PAN from its leading digits and length, or UnknownScheme.
+func DetectScheme(pan string) Scheme {
+	if !util.IsDigits(pan) {
+		return UnknownScheme
+	}
+	for _, r := range schemeRanges {
+		if len(pan) < len(r.low) || len(pan) < r.minLength || len(pan) > r.maxLength {
+			continue
+		}
+		prefix := pan[:len(r.low)]
+		if prefix >= r.low && prefix <= r.high {
+			return r.scheme
+		}
+	}
+	return UnknownScheme
+}
@@ -0,0 +1,162 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//Layouts of the common date and time fields, in the notation of the time package.
+const (
+	LayoutMMDDhhmmss   = "0102150405"   //DE7 transmission date and time
+	Layouthhmmss       = "150405"       //DE12 local time
+	LayoutMMDD         = "0102"         //DE13 local date, DE15 settlement date, DE17 capture date
+	LayoutYYMM         = "0601"         //DE14 expiration date
+	LayoutYYMMDDhhmmss = "060102150405" //DE12 local date and time of the 1993 version
+)
+
+//dateTimeField is a fixed numeric field holding a date, a time or both in the format of its layout.
+type dateTimeField struct {
+	*BitmapMessageField
+	Layout   string
+	Location *time.Location //nil for the location of the clock, e.g. local time
+	hasYear  bool
+	hasDate  bool
+}
+
+//NewDateTimeField creates a fixed field formatted with a layout of the time package, e.g. LayoutMMDDhhmmss. Times are
+//converted to loc, such as time.UTC for the transmission date and time; a nil loc keeps the location of the template
+//clock for local date and time fields.
+func NewDateTimeField(bitNumber int, name string, layout string, loc *time.Location) Field {
+	ref := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	return &dateTimeField{
+		BitmapMessageField: &BitmapMessageField{bitNumber, name, Numeric, Fixed, len(layout), NewFixedFieldPackerUnpacker(len(layout)), nil, nil},
+		Layout:             layout,
+		Location:           loc,
+		hasYear:            ref.Format(layout) != ref.AddDate(1, 0, 0).Format(layout),
+		hasDate:            ref.Format(layout) != ref.AddDate(0, 1, 1).Format(layout),
+	}
+}
+
+func (f *dateTimeField) location(now time.Time) *time.Location {
+	if f.Location != nil {
+		return f.Location
+	}
+	return now.Location()
+}
+
+//Format returns the time in the layout of the field.
+func (f *dateTimeField) Format(t time.Time) string {
+	return t.In(f.location(t)).Format(f.Layout)
+}
+
+//Parse reads a value of the field. Parts missing from the layout are taken from now: a date without a year is placed in
+//the year which brings it closest to now, and a time without a date falls on the date of now. 29 February is placed in a
+//leap year within a year of now, and rejected if there is none.
+func (f *dateTimeField) Parse(value string, now time.Time) (time.Time, error) {
+	loc := f.location(now)
+	t, err := time.ParseInLocation(f.Layout, value, loc)
+	if err != nil {
+		return t, errors.New(fmt.Sprint("Invalid date time ", value, " for field ", f.FieldNumber, ": ", err))
+	}
+	if f.hasYear {
+		return t, nil
+	}
+	now = now.In(loc)
+	if !f.hasDate {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+	}
+	var nearest time.Time
+	for _, year := range []int{now.Year(), now.Year() - 1, now.Year() + 1} {
+		other := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		if other.Day() != t.Day() {
+			continue //29 February outside a leap year
+		}
+		if nearest.IsZero() || absDuration(other.Sub(now)) < absDuration(nearest.Sub(now)) {
+			nearest = other
+		}
+	}
+	if nearest.IsZero() {
+		return nearest, errors.New(fmt.Sprint("Invalid date time ", value, " for field ", f.FieldNumber, ": no leap year within a year of ", now.Year()))
+	}
+	return nearest, nil
+}
+
+//AutoFill formats the time auto fill is applied.
+func (f *dateTimeField) AutoFill(msg Message, now time.Time) (string, error) {
+	return f.Format(now), nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+//dateTimeFormatter is implemented by date and time fields.
+type dateTimeFormatter interface {
+	Format(t time.Time) string
+	Parse(value string, now time.Time) (time.Time, error)
+}
+
+//now returns the time from the template clock.
+func (t *BitmapMessageTemplate) now() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+	return time.Now()
+}
+
+//SetAutoFillTime sets date and time fields, such as DE7, DE12 and DE13, to the time of the template clock when
+//ApplyAutoFill is called on a request without them. The fields must have been created with NewDateTimeField.
+func (t *BitmapMessageTemplate) SetAutoFillTime(fieldNrs ...int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return errors.New(fmt.Sprint("Cannot set auto fill of fields ", fieldNrs, " on a frozen template"))
+	}
+	for _, fieldNr := range fieldNrs {
+		filler, ok := t.Fields[fieldNr].(*dateTimeField)
+		if !ok {
+			return errors.New(fmt.Sprint("Field ", fieldNr, " is not a date time field"))
+		}
+		if t.AutoFill == nil {
+			t.AutoFill = make(map[int]AutoFiller)
+		}
+		t.AutoFill[fieldNr] = filler
+	}
+	return nil
+}
+
+//GetTime parses a date or time field, completing a partial date from the template clock.
+func (m *BitmapMessage) GetTime(fieldNr int) (time.Time, bool, error) {
+	value, ok := m.GetString(fieldNr)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	field, err := m.GetFieldDef(fieldNr)
+	if err != nil {
+		return time.Time{}, true, err
+	}
+	f, isDateTime := field.(dateTimeFormatter)
+	if !isDateTime {
+		return time.Time{}, true, errors.New(fmt.Sprint("Field ", fieldNr, " is not a date time field"))
+	}
+	t, err := f.Parse(value, m.now())
+	return t, true, err
+}
+
+//SetTime sets a date or time field to the time in the layout of the field.
+func (m *BitmapMessage) SetTime(fieldNr int, t time.Time) error {
+	field, err := m.GetFieldDef(fieldNr)
+	if err != nil {
+		return err
+	}
+	f, isDateTime := field.(dateTimeFormatter)
+	if !isDateTime {
+		return errors.New(fmt.Sprint("Field ", fieldNr, " is not a date time field"))
+	}
+	m.SetString(fieldNr, f.Format(t))
+	return nil
+}
@@ -0,0 +1,91 @@
+package go8583
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateTimeParse(t *testing.T) {
+	tests := []struct {
+		layout string
+		value  string
+		now    time.Time
+		want   time.Time
+	}{
+		{LayoutMMDDhhmmss, "1019123456", time.Date(2026, 10, 19, 12, 35, 0, 0, time.UTC), time.Date(2026, 10, 19, 12, 34, 56, 0, time.UTC)},
+		//December received in January belongs to the year before
+		{LayoutMMDDhhmmss, "1231235959", time.Date(2027, 1, 1, 0, 0, 5, 0, time.UTC), time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)},
+		//January received in December, e.g. a settlement date, belongs to the year after
+		{LayoutMMDD, "0101", time.Date(2026, 12, 31, 22, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{LayoutMMDD, "0701", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{LayoutMMDD, "0229", time.Date(2028, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{LayoutMMDD, "0229", time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{LayoutMMDD, "0229", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{Layouthhmmss, "235959", time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 23, 59, 59, 0, time.UTC)},
+		{LayoutYYMMDDhhmmss, "991231235959", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		f := NewDateTimeField(7, "dateTime", test.layout, time.UTC).(*dateTimeField)
+		got, err := f.Parse(test.value, test.now)
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("%s received %s parsed as %s %v, want %s", test.value, test.now, got, err, test.want)
+		}
+		if formatted := f.Format(got); formatted != test.value {
+			t.Errorf("%s formatted as %s", got, formatted)
+		}
+	}
+
+	f := NewDateTimeField(13, "localDate", LayoutMMDD, time.UTC).(*dateTimeField)
+	for _, value := range []string{"0230", "1301", "0000", "12AB"} {
+		if got, err := f.Parse(value, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)); err == nil {
+			t.Errorf("parsed invalid date %s as %s", value, got)
+		}
+	}
+	if got, err := f.Parse("0229", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("parsed 0229 with no leap year within a year as %s", got)
+	}
+}
+
+func TestGetSetTime(t *testing.T) {
+	now := time.Date(2027, 1, 1, 0, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60))
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewDateTimeField(7, "transmissionDateTime", LayoutMMDDhhmmss, time.UTC),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewDateTimeField(12, "localTime", Layouthhmmss, nil),
+		NewDateTimeField(13, "localDate", LayoutMMDD, nil),
+	), Now: func() time.Time { return now }}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+
+	for _, fieldNr := range []int{7, 12, 13} {
+		if err := msg.SetTime(fieldNr, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[int]string{7: "1231223000", 12: "003000", 13: "0101"}
+	for fieldNr, value := range want {
+		if got, _ := msg.GetString(fieldNr); got != value {
+			t.Errorf("DE%d is %s, want %s", fieldNr, got, value)
+		}
+	}
+	if got, ok, err := msg.GetTime(7); !ok || err != nil || !got.Equal(now) {
+		t.Errorf("DE7 read back as %s %t %v, want %s", got, ok, err, now)
+	}
+	if got, ok, err := msg.GetTime(12); !ok || err != nil || !got.Equal(now) {
+		t.Errorf("DE12 read back as %s %t %v, want %s", got, ok, err, now)
+	}
+	if got, _, err := msg.GetTime(13); err != nil || got.Year() != 2027 || got.YearDay() != 1 {
+		t.Errorf("DE13 read back as %s %v, want 1 January 2027", got, err)
+	}
+
+	if _, ok, err := (&BitmapMessage{BitmapMessageTemplate: tmpl}).GetTime(7); ok || err != nil {
+		t.Errorf("unset DE7 read as set %t %v", ok, err)
+	}
+	msg.SetString(11, "000001")
+	if _, _, err := msg.GetTime(11); err == nil {
+		t.Error("read the STAN as a time")
+	}
+	if err := msg.SetTime(11, now); err == nil {
+		t.Error("set the STAN to a time")
+	}
+}
@@ -0,0 +1,200 @@
+package go8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//Subfields of track 2 data fields.
+const (
+	Track2Pan = iota + 1
+	Track2ExpirationDate
+	Track2ServiceCode
+	Track2DiscretionaryData
+)
+
+//Subfields of track 1 data fields.
+const (
+	Track1FormatCode = iota + 1
+	Track1Pan
+	Track1Name
+	Track1ExpirationDate
+	Track1ServiceCode
+	Track1DiscretionaryData
+)
+
+type delimitedField struct {
+	*BitmapMessageField
+	*BitmapMessageTemplate
+	order     []int
+	delimiter string
+	bcd       bool
+}
+
+//NewDelimitedField creates a field made up of subfields in the order given. Variable length subfields are terminated by the delimiter,
+//fixed length subfields are taken by position. The last subfield runs to the end of the field.
+func NewDelimitedField(fieldNumber int, name string, size int, delimiter string, fieldPackerUnpacker PackerUnpacker, fields []Field) Field {
+	field := &delimitedField{&BitmapMessageField{fieldNumber, name, AlphaNumericSpecial, LlVar, size, fieldPackerUnpacker, nil, nil},
+		new(BitmapMessageTemplate),
+		nil,
+		delimiter,
+		false,
+	}
+	field.Fields = CreateFields(fields...)
+	for _, f := range fields {
+		field.order = append(field.order, f.GetFieldNumber())
+	}
+	return field
+}
+
+//NewBcdDelimitedField creates a delimited field packed as BCD, where the hex digit D is the delimiter and an odd number of digits is padded with F.
+//The size is the maximum number of digits, delimiters included, not the number of packed bytes.
+func NewBcdDelimitedField(fieldNumber int, name string, size int, fieldPackerUnpacker PackerUnpacker, fields []Field) Field {
+	field := NewDelimitedField(fieldNumber, name, size, "D", fieldPackerUnpacker, fields).(*delimitedField)
+	field.Type = Binary
+	field.bcd = true
+	return field
+}
+
+func track2Fields() []Field {
+	return []Field{
+		NewLlVarField(Track2Pan, "pan", 19, Numeric),
+		NewFixedField(Track2ExpirationDate, "expirationDate", 4, Numeric),
+		NewFixedField(Track2ServiceCode, "serviceCode", 3, Numeric),
+		NewLlVarField(Track2DiscretionaryData, "discretionaryData", 37, AlphaNumericSpecial),
+	}
+}
+
+//track2Pan returns the PAN from the track 2 data of DE35, either its PAN subfield or, when DE35 is a plain field, the
+//digits before the = or D separator of PAN=YYMMSSSdiscretionary.
+func track2Pan(msg Message) (pan string, isSet bool) {
+	if pan, isSet = msg.GetSubField(35, Track2Pan); isSet {
+		return pan, true
+	}
+	value, isSet := msg.GetField(35)
+	if !isSet || value == "" {
+		return "", false
+	}
+	if end := strings.IndexAny(value, "=Dd"); end >= 0 {
+		return value[:end], true
+	}
+	return value, true
+}
+
+//NewTrack2Field creates a track 2 field such as DE35, PAN=YYMMSSSdiscretionary.
+func NewTrack2Field(fieldNumber int, name string, fieldPackerUnpacker PackerUnpacker) Field {
+	return NewDelimitedField(fieldNumber, name, 37, "=", fieldPackerUnpacker, track2Fields())
+}
+
+//NewBcdTrack2Field creates a BCD packed track 2 field, PAN D YYMM SSS discretionary, of at most 37 digits.
+func NewBcdTrack2Field(fieldNumber int, name string, fieldPackerUnpacker PackerUnpacker) Field {
+	return NewBcdDelimitedField(fieldNumber, name, 37, fieldPackerUnpacker, track2Fields())
+}
+
+//NewTrack1Field creates a track 1 field such as DE45, BPAN^NAME^YYMMSSSdiscretionary.
+func NewTrack1Field(fieldNumber int, name string, fieldPackerUnpacker PackerUnpacker) Field {
+	return NewDelimitedField(fieldNumber, name, 76, "^", fieldPackerUnpacker, []Field{
+		NewFixedField(Track1FormatCode, "formatCode", 1, Alpha),
+		NewLlVarField(Track1Pan, "pan", 19, Numeric),
+		NewLlVarField(Track1Name, "name", 26, AlphaNumericSpecial),
+		NewFixedField(Track1ExpirationDate, "expirationDate", 4, Numeric),
+		NewFixedField(Track1ServiceCode, "serviceCode", 3, Numeric),
+		NewLlVarField(Track1DiscretionaryData, "discretionaryData", 76, AlphaNumericSpecial),
+	})
+}
+
+func (f *delimitedField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value, err = f.unpackSubFields(fieldData)
+	return newOffset, value, err
+}
+
+func (f *delimitedField) PackField(value FieldValue) (data []byte, err error) {
+	subFieldData, err := f.packSubFields(value)
+	if err != nil {
+		return nil, err
+	}
+	return f.Pack(subFieldData)
+}
+
+func (f *delimitedField) unpackSubFields(data []byte) (value FieldValue, err error) {
+	values := make(map[int]FieldValue)
+	if f.bcd {
+		data = []byte(strings.TrimRight(strings.ToUpper(hex.EncodeToString(data)), "F"))
+	}
+	offset := 0
+	for i, subFieldNr := range f.order {
+		if offset >= len(data) {
+			break
+		}
+		field := f.Fields[subFieldNr]
+		if i == len(f.order)-1 {
+			values[subFieldNr] = FieldValue{Value: string(data[offset:])}
+			break
+		}
+		if field.GetLength() == Fixed {
+			var subValue FieldValue
+			if offset, subValue, err = field.UnpackField(offset, data); err != nil {
+				return value, errors.New(fmt.Sprint("Error unpacking subfield ", subFieldNr, ": ", err))
+			}
+			values[subFieldNr] = subValue
+			continue
+		}
+		end := bytes.Index(data[offset:], []byte(f.delimiter))
+		if end < 0 {
+			values[subFieldNr] = FieldValue{Value: string(data[offset:])}
+			break
+		}
+		values[subFieldNr] = FieldValue{Value: string(data[offset : offset+end])}
+		offset += end + len(f.delimiter)
+	}
+	return FieldValue{FieldValues: values}, nil
+}
+
+func (f *delimitedField) packSubFields(value FieldValue) (data []byte, err error) {
+	values := value.FieldValues
+	buf := new(bytes.Buffer)
+	for i, subFieldNr := range f.order {
+		field := f.Fields[subFieldNr]
+		value := values[subFieldNr]
+		if i == len(f.order)-1 {
+			buf.WriteString(value.Value)
+			break
+		}
+		if field.GetLength() == Fixed {
+			if _, set := values[subFieldNr]; !set {
+				//Nothing further is written once a positional subfield is absent.
+				break
+			}
+			subFieldData, err := field.PackField(value)
+			if err != nil {
+				return nil, errors.New(fmt.Sprint("Error packing subfield ", subFieldNr, ": ", err))
+			}
+			buf.Write(subFieldData)
+			continue
+		}
+		buf.WriteString(value.Value)
+		buf.WriteString(f.delimiter)
+	}
+	if buf.Len() > f.Size {
+		return nil, errors.New(fmt.Sprint("Field ", f.FieldNumber, " length ", buf.Len(), " exceeds ", f.Size))
+	}
+	if !f.bcd {
+		return buf.Bytes(), nil
+	}
+	digits := buf.String()
+	if len(digits)%2 != 0 {
+		digits += "F"
+	}
+	data, err = hex.DecodeString(digits)
+	if err != nil {
+		return nil, errors.New(fmt.Sprint("Field ", f.FieldNumber, " is not valid BCD"))
+	}
+	return data, nil
+}
@@ -0,0 +1,38 @@
+package go8583
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestBcdTrack2Field(t *testing.T) {
+	field := NewBcdTrack2Field(35, "track2", NewVariableFieldPackerUnpacker(2)).(*delimitedField)
+	value := FieldValue{FieldValues: map[int]FieldValue{
+		Track2Pan:               {Value: "4761739001010119"},
+		Track2ExpirationDate:    {Value: "2512"},
+		Track2ServiceCode:       {Value: "201"},
+		Track2DiscretionaryData: {Value: "1234567890123"},
+	}}
+	data, err := field.PackField(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "19" + "4761739001010119D25122011234567890123F"; string(data[:2])+strings.ToUpper(hex.EncodeToString(data[2:])) != want {
+		t.Errorf("packed %s %X, want %s", data[:2], data[2:], want)
+	}
+	_, unpacked, err := field.UnpackField(0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for subFieldNr, want := range value.FieldValues {
+		if got := unpacked.FieldValues[subFieldNr].Value; got != want.Value {
+			t.Errorf("subfield %d is %q, want %q", subFieldNr, got, want.Value)
+		}
+	}
+
+	value.FieldValues[Track2DiscretionaryData] = FieldValue{Value: "12345678901234"}
+	if _, err = field.PackField(value); err == nil {
+		t.Error("packed 38 digits without error")
+	}
+}
@@ -0,0 +1,16 @@
+/*
+Package go8583 packs and unpacks ISO 8583 messages described by a BitmapMessageTemplate.
+
+Concurrency
+
+A template is built once, then frozen with Freeze, e.g.
+
+	var tmpl = (&go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(...)}).Freeze()
+
+A frozen template is immutable and may be shared by any number of goroutines packing and unpacking their own
+messages. Fields may be added with AddField, which is locked, until the template is frozen.
+
+A BitmapMessage is not safe for concurrent use. Each goroutine should use its own message, e.g. taken from a
+MessagePool, or wrap a message shared between goroutines in a SyncMessage.
+*/
+package go8583
@@ -0,0 +1,71 @@
+package go8583
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583/dukpt"
+	"github.com/doswell/go8583/mac"
+	"github.com/doswell/go8583/pin"
+)
+
+//Dukpt derives the PIN and MAC keys of a message from the key serial number (KSN) it carries.
+type Dukpt struct {
+	Deriver dukpt.Deriver
+	//KsnPath is the path of the field carrying the KSN, such as "53" or "62.2". The KSN may be binary or hex.
+	KsnPath string
+}
+
+//Ksn returns the KSN of the message.
+func (d *Dukpt) Ksn(msg *BitmapMessage) ([]byte, error) {
+	value, isSet := msg.Get(d.KsnPath)
+	if !isSet {
+		return nil, errors.New(fmt.Sprint("KSN field ", d.KsnPath, " is not set"))
+	}
+	if len(value) == dukpt.TdesKsnSize || len(value) == dukpt.AesKsnSize {
+		return []byte(value), nil
+	}
+	ksn, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, errors.New(fmt.Sprint("Invalid KSN in field ", d.KsnPath))
+	}
+	return ksn, nil
+}
+
+//WorkingKey derives the key for the usage from the KSN of the message.
+func (d *Dukpt) WorkingKey(msg *BitmapMessage, usage dukpt.Usage) ([]byte, error) {
+	ksn, err := d.Ksn(msg)
+	if err != nil {
+		return nil, err
+	}
+	return d.Deriver.WorkingKey(ksn, usage)
+}
+
+//PinKey returns the key for the PIN block of the message, for SetPinBlock, GetPin and TranslatePinBlock.
+func (d *Dukpt) PinKey(msg *BitmapMessage) (pin.Key, error) {
+	key, err := d.WorkingKey(msg, dukpt.PinKey)
+	if err != nil {
+		return nil, err
+	}
+	if d.Deriver.WorkingKeyType().IsAes() {
+		return pin.NewAesKey(key)
+	}
+	return pin.NewTdesKey(key)
+}
+
+//MacProvider returns the MAC provider for the message, ANSI X9.19 for TDES keys and AES-CMAC for AES keys. usage is
+//dukpt.MacRequest or dukpt.MacResponse.
+func (d *Dukpt) MacProvider(msg *BitmapMessage, usage dukpt.Usage) (mac.Provider, error) {
+	if usage != dukpt.MacRequest && usage != dukpt.MacResponse {
+		return nil, errors.New("Usage is not a MAC key")
+	}
+	key, err := d.WorkingKey(msg, usage)
+	if err != nil {
+		return nil, err
+	}
+	if d.Deriver.WorkingKeyType().IsAes() {
+		return mac.NewAesCmac(key)
+	}
+	return mac.NewIso9797Alg3(key, mac.PaddingMethod1)
+}
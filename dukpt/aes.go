@@ -0,0 +1,144 @@
+package dukpt
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+)
+
+//AesKsnSize is the length of an AES DUKPT key serial number, the 8 byte initial key ID followed by a 32 bit transaction counter.
+const AesKsnSize = 12
+
+//Key usage indicators of the AES DUKPT derivation data.
+var aesUsages = map[Usage]uint16{
+	PinKey:       0x1000,
+	MacRequest:   0x2000,
+	MacResponse:  0x2001,
+	DataRequest:  0x3000,
+	DataResponse: 0x3001,
+}
+
+const (
+	keyDerivationUsage        = 0x8000
+	initialKeyDerivationUsage = 0x8001
+)
+
+type aesDeriver struct {
+	bdk            []byte
+	initialKey     []byte
+	keyType        KeyType
+	workingKeyType KeyType
+}
+
+//NewAesBdk creates a host side deriver for AES DUKPT from an AES base derivation key of 16, 24 or 32 bytes. Working keys
+//are of workingKeyType, which may not be longer than the BDK.
+func NewAesBdk(bdk []byte, workingKeyType KeyType) (Deriver, error) {
+	keyType, err := aesKeyType(bdk)
+	if err != nil {
+		return nil, err
+	}
+	if workingKeyType.Size() > keyType.Size() {
+		return nil, errors.New("Working key cannot be longer than the BDK")
+	}
+	return &aesDeriver{bdk: append([]byte{}, bdk...), keyType: keyType, workingKeyType: workingKeyType}, nil
+}
+
+//NewAesInitialKey creates a deriver from a terminal's initial key, for terminal simulators.
+func NewAesInitialKey(initialKey []byte, workingKeyType KeyType) (Deriver, error) {
+	keyType, err := aesKeyType(initialKey)
+	if err != nil {
+		return nil, err
+	}
+	if workingKeyType.Size() > keyType.Size() {
+		return nil, errors.New("Working key cannot be longer than the initial key")
+	}
+	return &aesDeriver{initialKey: append([]byte{}, initialKey...), keyType: keyType, workingKeyType: workingKeyType}, nil
+}
+
+func aesKeyType(key []byte) (KeyType, error) {
+	switch len(key) {
+	case 16:
+		return Aes128, nil
+	case 24:
+		return Aes192, nil
+	case 32:
+		return Aes256, nil
+	}
+	return 0, errors.New("AES DUKPT key must be 16, 24 or 32 bytes")
+}
+
+func (d *aesDeriver) WorkingKeyType() KeyType {
+	return d.workingKeyType
+}
+
+func (d *aesDeriver) WorkingKey(ksn []byte, usage Usage) ([]byte, error) {
+	if len(ksn) != AesKsnSize {
+		return nil, errors.New("AES DUKPT KSN must be 12 bytes")
+	}
+	usageIndicator, ok := aesUsages[usage]
+	if !ok {
+		return nil, errors.New("Unknown key usage")
+	}
+	key := d.initialKey
+	if key == nil {
+		var err error
+		if key, err = AesInitialKey(d.bdk, ksn[:8]); err != nil {
+			return nil, err
+		}
+	}
+
+	counter := binary.BigEndian.Uint32(ksn[8:])
+	var workingCounter uint32
+	for bit := uint32(1 << 31); bit > 0; bit >>= 1 {
+		if counter&bit == 0 {
+			continue
+		}
+		workingCounter |= bit
+		var err error
+		if key, err = aesDeriveKey(key, derivationData(keyDerivationUsage, d.keyType, ksn[4:8], workingCounter), d.keyType); err != nil {
+			return nil, err
+		}
+	}
+	return aesDeriveKey(key, derivationData(usageIndicator, d.workingKeyType, ksn[4:8], counter), d.workingKeyType)
+}
+
+//AesInitialKey derives the initial key loaded into a terminal, from the BDK and the 8 byte initial key ID.
+func AesInitialKey(bdk []byte, initialKeyId []byte) ([]byte, error) {
+	keyType, err := aesKeyType(bdk)
+	if err != nil {
+		return nil, err
+	}
+	if len(initialKeyId) != 8 {
+		return nil, errors.New("AES DUKPT initial key ID must be 8 bytes")
+	}
+	data := derivationData(initialKeyDerivationUsage, keyType, initialKeyId[:4], binary.BigEndian.Uint32(initialKeyId[4:]))
+	return aesDeriveKey(bdk, data, keyType)
+}
+
+//derivationData builds the 16 byte block encrypted to derive a key, identifying the usage and type of the key and the
+//transaction it is for.
+func derivationData(usage uint16, keyType KeyType, id []byte, counter uint32) []byte {
+	data := make([]byte, 16)
+	data[0] = 0x01 //Version
+	data[1] = 0x01 //Key block counter
+	binary.BigEndian.PutUint16(data[2:], usage)
+	binary.BigEndian.PutUint16(data[4:], uint16(keyType))
+	binary.BigEndian.PutUint16(data[6:], uint16(keyType.Size()*8))
+	copy(data[8:], id)
+	binary.BigEndian.PutUint32(data[12:], counter)
+	return data
+}
+
+//aesDeriveKey encrypts the derivation data under key, once per 16 bytes of the derived key.
+func aesDeriveKey(key []byte, data []byte, keyType KeyType) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	derived := make([]byte, (keyType.Size()+15)/16*16)
+	for i := 0; i < len(derived); i += 16 {
+		data[1] = byte(i/16 + 1)
+		block.Encrypt(derived[i:], data)
+	}
+	return derived[:keyType.Size()], nil
+}
@@ -0,0 +1,53 @@
+//Package dukpt derives ANSI X9.24 derived unique key per transaction (DUKPT) keys, TDES DUKPT from X9.24-1 and AES DUKPT from
+//X9.24-3, from a base derivation key or a terminal's initial key and the key serial number (KSN) sent with each transaction.
+package dukpt
+
+//Usage is the purpose of a working key.
+type Usage int
+
+const (
+	//PinKey encrypts the PIN block, DE52.
+	PinKey Usage = iota + 1
+	//MacRequest MACs messages from the terminal.
+	MacRequest
+	//MacResponse MACs messages to the terminal.
+	MacResponse
+	//DataRequest encrypts data from the terminal.
+	DataRequest
+	//DataResponse encrypts data to the terminal.
+	DataResponse
+)
+
+//KeyType is the algorithm and length of a derived key.
+type KeyType int
+
+const (
+	Tdes2 KeyType = iota //Double length TDES
+	Tdes3                //Triple length TDES
+	Aes128
+	Aes192
+	Aes256
+)
+
+//IsAes returns true for AES key types.
+func (t KeyType) IsAes() bool {
+	return t >= Aes128
+}
+
+//Size returns the key length in bytes.
+func (t KeyType) Size() int {
+	switch t {
+	case Tdes2, Aes128:
+		return 16
+	case Tdes3, Aes192:
+		return 24
+	}
+	return 32
+}
+
+//Deriver derives the working key for a transaction from its KSN. Implement it to derive keys in an HSM.
+type Deriver interface {
+	//WorkingKeyType returns the type of the keys returned by WorkingKey.
+	WorkingKeyType() KeyType
+	WorkingKey(ksn []byte, usage Usage) ([]byte, error)
+}
@@ -0,0 +1,119 @@
+package dukpt
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/doswell/go8583/pin"
+)
+
+const (
+	tdesBdk = "0123456789ABCDEFFEDCBA9876543210"
+	aesBdk  = "FEDCBA9876543210F1F1F1F1F1F1F1F1"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func checkHex(t *testing.T, name string, got []byte, err error, want string) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+		return
+	}
+	if gotHex := strings.ToUpper(hex.EncodeToString(got)); gotHex != want {
+		t.Errorf("%s is %s, want %s", name, gotHex, want)
+	}
+}
+
+//TestTdesKeys checks the keys of ANSI X9.24-1 for the first KSN.
+func TestTdesKeys(t *testing.T) {
+	ipek, err := TdesInitialKey(mustHex(t, tdesBdk), mustHex(t, "FFFF9876543210E00000"))
+	checkHex(t, "IPEK", ipek, err, "6AC292FAA1315B4D858AB3A3D7D5933A")
+	key, err := TdesCurrentKey(ipek, mustHex(t, "FFFF9876543210E00001"))
+	checkHex(t, "current key", key, err, "042666B49184CFA368DE9628D0397BC9")
+	tests := []struct {
+		usage Usage
+		key   string
+	}{
+		{PinKey, "042666B49184CF5C68DE9628D0397B36"},
+		{MacRequest, "042666B4918430A368DE9628D03984C9"},
+	}
+	for _, test := range tests {
+		workingKey, err := TdesWorkingKey(key, test.usage)
+		checkHex(t, "working key", workingKey, err, test.key)
+	}
+}
+
+//TestTdesPinBlocks checks the X9.24-1 PIN blocks for PIN 1234 and PAN 4012345678909, format 0.
+func TestTdesPinBlocks(t *testing.T) {
+	tests := []struct {
+		ksn, pinBlock string
+	}{
+		{"FFFF9876543210E00001", "1B9C1845EB993A7A"},
+		{"FFFF9876543210E00002", "10A01C8D02C69107"},
+		{"FFFF9876543210E00003", "18DC07B94797B466"},
+		{"FFFF9876543210E00004", "0BC79509D5645DF7"},
+	}
+	deriver, err := NewTdesBdk(mustHex(t, tdesBdk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ipek, _ := TdesInitialKey(mustHex(t, tdesBdk), mustHex(t, "FFFF9876543210E00000"))
+	terminal, err := NewTdesInitialKey(ipek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		for _, d := range []Deriver{deriver, terminal} {
+			workingKey, err := d.WorkingKey(mustHex(t, test.ksn), PinKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := pin.NewTdesKey(workingKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, err := pin.Encrypt(key, pin.Format0, "1234", "4012345678909")
+			checkHex(t, "PIN block "+test.ksn, block, err, test.pinBlock)
+		}
+	}
+}
+
+//TestAesKeys checks the keys of ANSI X9.24-3 for the first KSN.
+func TestAesKeys(t *testing.T) {
+	initialKey, err := AesInitialKey(mustHex(t, aesBdk), mustHex(t, "1234567890123456"))
+	checkHex(t, "initial key", initialKey, err, "1273671EA26AC29AFA4D1084127652A1")
+	deriver, err := NewAesBdk(mustHex(t, aesBdk), Aes128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := deriver.WorkingKey(mustHex(t, "123456789012345600000001"), PinKey)
+	checkHex(t, "PIN key", key, err, "AF8CB133A78F8DC2D1359F18527593FB")
+	terminal, err := NewAesInitialKey(initialKey, Aes128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err = terminal.WorkingKey(mustHex(t, "123456789012345600000001"), PinKey)
+	checkHex(t, "terminal PIN key", key, err, "AF8CB133A78F8DC2D1359F18527593FB")
+}
+
+func TestInvalid(t *testing.T) {
+	if _, err := NewTdesBdk(mustHex(t, "0123456789ABCDEF")); err == nil {
+		t.Error("created a TDES deriver from an 8 byte key without error")
+	}
+	deriver, _ := NewTdesBdk(mustHex(t, tdesBdk))
+	if _, err := deriver.WorkingKey(mustHex(t, "FFFF9876543210E0"), PinKey); err == nil {
+		t.Error("derived a key from a short KSN without error")
+	}
+	if _, err := NewAesBdk(mustHex(t, aesBdk), Aes256); err == nil {
+		t.Error("created an AES deriver with working keys longer than the BDK without error")
+	}
+}
@@ -0,0 +1,171 @@
+package dukpt
+
+import (
+	"crypto/des"
+	"errors"
+)
+
+//TdesKsnSize is the length of a TDES DUKPT key serial number, the initial key ID followed by a 21 bit transaction counter.
+const TdesKsnSize = 10
+
+var keyRegisterMask = []byte{0xC0, 0xC0, 0xC0, 0xC0, 0x00, 0x00, 0x00, 0x00, 0xC0, 0xC0, 0xC0, 0xC0, 0x00, 0x00, 0x00, 0x00}
+
+//tdesVariants are XORed with both halves of the current key to give each working key.
+var tdesVariants = map[Usage][]byte{
+	PinKey:       {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF},
+	MacRequest:   {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x00},
+	MacResponse:  {0x00, 0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00},
+	DataRequest:  {0x00, 0x00, 0x00, 0x00, 0x00, 0xFF, 0x00, 0x00},
+	DataResponse: {0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x00},
+}
+
+type tdesDeriver struct {
+	bdk  []byte
+	ipek []byte
+}
+
+//NewTdesBdk creates a host side deriver for TDES DUKPT from a double length base derivation key.
+func NewTdesBdk(bdk []byte) (Deriver, error) {
+	if len(bdk) != 16 {
+		return nil, errors.New("TDES DUKPT BDK must be 16 bytes")
+	}
+	return &tdesDeriver{bdk: append([]byte{}, bdk...)}, nil
+}
+
+//NewTdesInitialKey creates a deriver from a terminal's initial PIN encryption key (IPEK), for terminal simulators.
+//Only KSNs with the initial key ID the IPEK was derived for give the right keys.
+func NewTdesInitialKey(ipek []byte) (Deriver, error) {
+	if len(ipek) != 16 {
+		return nil, errors.New("TDES DUKPT initial key must be 16 bytes")
+	}
+	return &tdesDeriver{ipek: append([]byte{}, ipek...)}, nil
+}
+
+func (d *tdesDeriver) WorkingKeyType() KeyType {
+	return Tdes2
+}
+
+func (d *tdesDeriver) WorkingKey(ksn []byte, usage Usage) ([]byte, error) {
+	ipek := d.ipek
+	if ipek == nil {
+		var err error
+		if ipek, err = TdesInitialKey(d.bdk, ksn); err != nil {
+			return nil, err
+		}
+	}
+	key, err := TdesCurrentKey(ipek, ksn)
+	if err != nil {
+		return nil, err
+	}
+	return TdesWorkingKey(key, usage)
+}
+
+//TdesInitialKey derives the initial PIN encryption key (IPEK) loaded into a terminal, from the BDK and the terminal's KSN.
+func TdesInitialKey(bdk []byte, ksn []byte) ([]byte, error) {
+	if len(ksn) != TdesKsnSize {
+		return nil, errors.New("TDES DUKPT KSN must be 10 bytes")
+	}
+	if len(bdk) != 16 {
+		return nil, errors.New("TDES DUKPT BDK must be 16 bytes")
+	}
+	initialKsn := make([]byte, 8)
+	copy(initialKsn, ksn)
+	initialKsn[7] &= 0xE0
+
+	ipek := make([]byte, 16)
+	for i, key := range [][]byte{bdk, xor(bdk, keyRegisterMask)} {
+		block, err := des.NewTripleDESCipher(append(append([]byte{}, key...), key[:8]...))
+		if err != nil {
+			return nil, err
+		}
+		block.Encrypt(ipek[i*8:], initialKsn)
+	}
+	return ipek, nil
+}
+
+//TdesCurrentKey derives the key for the transaction counter of the KSN from the IPEK, before any variant is applied.
+func TdesCurrentKey(ipek []byte, ksn []byte) ([]byte, error) {
+	if len(ksn) != TdesKsnSize {
+		return nil, errors.New("TDES DUKPT KSN must be 10 bytes")
+	}
+	if len(ipek) != 16 {
+		return nil, errors.New("TDES DUKPT initial key must be 16 bytes")
+	}
+	counter := uint32(ksn[7]&0x1F)<<16 | uint32(ksn[8])<<8 | uint32(ksn[9])
+
+	//The register holds the rightmost 64 bits of the KSN, with counter bits added one at a time.
+	register := make([]byte, 8)
+	copy(register, ksn[2:])
+	register[5] &= 0xE0
+	register[6] = 0
+	register[7] = 0
+
+	key := append([]byte{}, ipek...)
+	for bit := uint32(1 << 20); bit > 0; bit >>= 1 {
+		if counter&bit == 0 {
+			continue
+		}
+		register[5] |= byte(bit >> 16)
+		register[6] |= byte(bit >> 8)
+		register[7] |= byte(bit)
+		var err error
+		if key, err = nonReversibleKey(key, register); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+//TdesWorkingKey applies the variant for the usage to a current key. Data keys are also encrypted under themselves.
+func TdesWorkingKey(key []byte, usage Usage) ([]byte, error) {
+	variant, ok := tdesVariants[usage]
+	if !ok {
+		return nil, errors.New("Unknown key usage")
+	}
+	workingKey := xor(key, append(append([]byte{}, variant...), variant...))
+	if usage != DataRequest && usage != DataResponse {
+		return workingKey, nil
+	}
+	block, err := des.NewTripleDESCipher(append(append([]byte{}, workingKey...), workingKey[:8]...))
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, 16)
+	block.Encrypt(dataKey[:8], workingKey[:8])
+	block.Encrypt(dataKey[8:], workingKey[8:])
+	return dataKey, nil
+}
+
+//nonReversibleKey is the non-reversible key generation process, deriving a new key from the key and the KSN register.
+func nonReversibleKey(key []byte, register []byte) ([]byte, error) {
+	newKey := make([]byte, 16)
+	right, err := nonReversibleHalf(key, register)
+	if err != nil {
+		return nil, err
+	}
+	left, err := nonReversibleHalf(xor(key, keyRegisterMask), register)
+	if err != nil {
+		return nil, err
+	}
+	copy(newKey, left)
+	copy(newKey[8:], right)
+	return newKey, nil
+}
+
+func nonReversibleHalf(key []byte, register []byte) ([]byte, error) {
+	block, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, err
+	}
+	half := xor(register, key[8:])
+	block.Encrypt(half, half)
+	return xor(half, key[8:]), nil
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
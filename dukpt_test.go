@@ -0,0 +1,65 @@
+package go8583
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/doswell/go8583/dukpt"
+	"github.com/doswell/go8583/pin"
+)
+
+//TestDukptMessage encrypts the PIN block and MACs a message with the keys for the KSN it carries.
+func TestDukptMessage(t *testing.T) {
+	bdk, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	deriver, err := dukpt.NewTdesBdk(bdk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &BitmapMessageTemplate{Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewFixedBinaryField(52, "pinBlock", 64),
+		NewFixedField(53, "ksn", 20, AlphaNumeric),
+		NewFixedBinaryField(64, "mac", 64),
+	)}
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(2, "4012345678909")
+	msg.SetString(11, "000001")
+	msg.SetString(53, "FFFF9876543210E00001")
+
+	keys := &Dukpt{Deriver: deriver, KsnPath: "53"}
+	pinKey, err := keys.PinKey(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = msg.SetPinBlock(pinKey, pin.Format0, "1234"); err != nil {
+		t.Fatal(err)
+	}
+	block, _ := msg.GetBytes(52)
+	if got := strings.ToUpper(hex.EncodeToString(block)); got != "1B9C1845EB993A7A" {
+		t.Errorf("DE52 is %s, want the X9.24-1 PIN block 1B9C1845EB993A7A", got)
+	}
+
+	provider, err := keys.MacProvider(msg, dukpt.MacRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := &Mac{Provider: provider}
+	data, err := mac.Generate(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mac.Verify(msg, data); err != nil {
+		t.Error(err)
+	}
+	msg.SetString(11, "000002")
+	if data, err = msg.Pack(); err != nil {
+		t.Fatal(err)
+	}
+	if err = mac.Verify(msg, data); err == nil {
+		t.Error("verified the MAC of an altered message")
+	}
+}
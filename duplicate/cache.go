@@ -0,0 +1,126 @@
+package duplicate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/doswell/go8583/util"
+)
+
+//clone copies an entry, so the memory cache shares no response with its callers.
+func (e *Entry) clone() *Entry {
+	stored := *e
+	if e.Response != nil {
+		stored.Response = append([]byte{}, e.Response...)
+	}
+	return &stored
+}
+
+type memoryCache struct {
+	entries map[string]*Entry
+	lock    sync.Mutex
+}
+
+//NewMemoryCache creates a cache held in memory, lost on restart.
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]*Entry)}
+}
+
+func (c *memoryCache) Get(key string) (*Entry, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	return entry.clone(), true, nil
+}
+
+func (c *memoryCache) Put(key string, entry *Entry) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = entry.clone()
+	return nil
+}
+
+func (c *memoryCache) Expire(before time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, entry := range c.entries {
+		if entry.Created.Before(before) {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+type fileCache struct {
+	dir  string
+	lock sync.Mutex
+}
+
+//NewFileCache creates a cache keeping each entry as a JSON file in dir, so duplicates are still detected after a restart.
+func NewFileCache(dir string) (Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileCache{dir: dir}, nil
+}
+
+//path names the file of a key by its hash, as keys hold field values which may not be valid file names.
+func (c *fileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *fileCache) Get(key string) (*Entry, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	data, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	entry := new(Entry)
+	if err = json.Unmarshal(data, entry); err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (c *fileCache) Put(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return util.WriteFileAtomic(c.path(key), data, 0600)
+}
+
+func (c *fileCache) Expire(before time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	names, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		entry := new(Entry)
+		if err = json.Unmarshal(data, entry); err != nil || entry.Created.Before(before) {
+			os.Remove(name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+//Package duplicate detects requests a network transmits more than once, so a server can answer a duplicate with the response
+//it already sent rather than processing the request again.
+package duplicate
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/doswell/go8583"
+	"github.com/doswell/go8583/util"
+)
+
+//DefaultKeyFields identify a request: transmission date and time, STAN, acquirer, retrieval reference number and terminal.
+var DefaultKeyFields = []int{7, 11, 32, 37, 41}
+
+//Entry is a request seen within the window, with the packed response once one has been sent.
+type Entry struct {
+	Created  time.Time `json:"created"`
+	Response []byte    `json:"response,omitempty"`
+}
+
+//Cache keeps entries by key. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (entry *Entry, ok bool, err error)
+	Put(key string, entry *Entry) error
+	//Expire removes entries created before the time.
+	Expire(before time.Time) error
+}
+
+//Detector finds duplicate requests within a time window. It is safe for concurrent use.
+type Detector struct {
+	//KeyFields are the fields which identify a request, DefaultKeyFields if nil.
+	KeyFields []int
+	Window    time.Duration
+	Cache     Cache
+	//Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	lock       sync.Mutex
+	lastExpire time.Time
+}
+
+//NewDetector creates a detector which remembers requests for window.
+func NewDetector(cache Cache, window time.Duration) *Detector {
+	return &Detector{Window: window, Cache: cache}
+}
+
+func (d *Detector) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
+
+//Key returns the key of a request: its message type, with repeats such as 0201 treated as the original 0200, and the
+//values of the key fields. Each value is prefixed with its length, so a separator within a value cannot shift it into the
+//next field.
+func (d *Detector) Key(msg *go8583.BitmapMessage) string {
+	keyFields := d.KeyFields
+	if keyFields == nil {
+		keyFields = DefaultKeyFields
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(util.LeftPad2Len(strconv.FormatInt(int64(msg.GetMsgType()&^0x0001), 16), "0", 4))
+	for _, fieldNr := range keyFields {
+		value, _ := msg.GetString(fieldNr)
+		buf.WriteByte('|')
+		buf.WriteString(strconv.Itoa(len(value)))
+		buf.WriteByte(':')
+		buf.WriteString(value)
+	}
+	return buf.String()
+}
+
+//Check records the request and reports whether it was already seen within the window. For a duplicate the response sent
+//to the original is returned, or nil if the original has not been answered yet.
+func (d *Detector) Check(msg *go8583.BitmapMessage) (isDuplicate bool, response []byte, err error) {
+	key := d.Key(msg)
+	now := d.now()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if now.Sub(d.lastExpire) > d.Window {
+		if err = d.Cache.Expire(now.Add(-d.Window)); err != nil {
+			return false, nil, err
+		}
+		d.lastExpire = now
+	}
+
+	entry, ok, err := d.Cache.Get(key)
+	if err != nil {
+		return false, nil, err
+	}
+	if ok && now.Sub(entry.Created) <= d.Window {
+		return true, entry.Response, nil
+	}
+	return false, nil, d.Cache.Put(key, &Entry{Created: now})
+}
+
+//SetResponse records the packed response sent for a request, to be returned for its duplicates.
+func (d *Detector) SetResponse(request *go8583.BitmapMessage, response []byte) error {
+	key := d.Key(request)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	entry, ok, err := d.Cache.Get(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		entry = &Entry{Created: d.now()}
+	}
+	entry.Response = append([]byte{}, response...)
+	return d.Cache.Put(key, entry)
+}
@@ -0,0 +1,142 @@
+package duplicate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/doswell/go8583"
+)
+
+var testTemplate = (&go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+	go8583.NewFixedField(7, "transmissionDateTime", 10, go8583.Numeric),
+	go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
+	go8583.NewLlVarField(32, "acquiringInstId", 11, go8583.Numeric),
+	go8583.NewLlVarField(37, "retrievalReferenceNumber", 12, go8583.AlphaNumeric),
+	go8583.NewLlVarField(41, "terminalId", 8, go8583.AlphaNumeric),
+)}).Freeze()
+
+func request(msgType int, stan string) *go8583.BitmapMessage {
+	msg := &go8583.BitmapMessage{BitmapMessageTemplate: testTemplate}
+	msg.Init()
+	msg.SetMsgType(msgType)
+	msg.SetString(7, "1019123456")
+	msg.SetString(11, stan)
+	msg.SetString(32, "123456")
+	msg.SetString(37, "629212000001")
+	msg.SetString(41, "TERM0001")
+	return msg
+}
+
+func testCaches(t *testing.T) map[string]Cache {
+	fileCache, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Cache{"memory": NewMemoryCache(), "file": fileCache}
+}
+
+func TestCheck(t *testing.T) {
+	for name, cache := range testCaches(t) {
+		now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		d := NewDetector(cache, time.Minute)
+		d.Now = func() time.Time { return now }
+		check := func(msg *go8583.BitmapMessage, wantDuplicate bool, wantResponse string) {
+			t.Helper()
+			isDuplicate, response, err := d.Check(msg)
+			if err != nil || isDuplicate != wantDuplicate || string(response) != wantResponse {
+				t.Errorf("%s: %04X %s is duplicate %t with response %q %v, want %t %q", name, msg.GetMsgType(),
+					d.Key(msg), isDuplicate, response, err, wantDuplicate, wantResponse)
+			}
+		}
+
+		check(request(0x0200, "000001"), false, "")
+		check(request(0x0200, "000001"), true, "")
+		response := []byte("0210 response")
+		if err := d.SetResponse(request(0x0200, "000001"), response); err != nil {
+			t.Fatal(err)
+		}
+		response[0] = 'X'
+		check(request(0x0200, "000001"), true, "0210 response")
+		check(request(0x0201, "000001"), true, "0210 response")
+		check(request(0x0220, "000001"), false, "")
+		check(request(0x0200, "000002"), false, "")
+
+		now = now.Add(time.Minute)
+		check(request(0x0200, "000001"), true, "0210 response")
+		now = now.Add(time.Second)
+		check(request(0x0200, "000001"), false, "")
+		check(request(0x0200, "000001"), true, "")
+	}
+}
+
+func TestSetResponseBeforeCheck(t *testing.T) {
+	d := NewDetector(NewMemoryCache(), time.Minute)
+	if err := d.SetResponse(request(0x0200, "000001"), []byte("0210")); err != nil {
+		t.Fatal(err)
+	}
+	if isDuplicate, response, err := d.Check(request(0x0201, "000001")); err != nil || !isDuplicate || string(response) != "0210" {
+		t.Errorf("repeat is duplicate %t with response %q %v, want the stored response", isDuplicate, response, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	d := &Detector{KeyFields: []int{37, 41}}
+	first := request(0x0200, "000001")
+	first.SetString(37, "A|B")
+	first.SetString(41, "C")
+	second := request(0x0200, "000001")
+	second.SetString(37, "A")
+	second.SetString(41, "B|C")
+	if d.Key(first) == d.Key(second) {
+		t.Errorf("%q and %q share the key %s", "A|B,C", "A,B|C", d.Key(first))
+	}
+	if key := d.Key(first); key != "0200|3:A|B|1:C" {
+		t.Errorf("key is %s, want 0200|3:A|B|1:C", key)
+	}
+	if d.Key(request(0x0421, "000001")) != d.Key(request(0x0420, "000001")) {
+		t.Error("a repeat 0421 has a different key from the original 0420")
+	}
+}
+
+func TestCacheExpire(t *testing.T) {
+	created := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for name, cache := range testCaches(t) {
+		if err := cache.Put("old", &Entry{Created: created}); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.Put("new", &Entry{Created: created.Add(time.Minute), Response: []byte("0210")}); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.Expire(created.Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := cache.Get("old"); ok || err != nil {
+			t.Errorf("%s: expired entry still cached %v", name, err)
+		}
+		entry, ok, err := cache.Get("new")
+		if !ok || err != nil || !entry.Created.Equal(created.Add(time.Minute)) || string(entry.Response) != "0210" {
+			t.Errorf("%s: entry read back as %+v %t %v", name, entry, ok, err)
+		}
+		entry.Response[0] = 'X'
+		if entry, _, _ = cache.Get("new"); string(entry.Response) != "0210" {
+			t.Errorf("%s: changing a returned entry changed the cache", name)
+		}
+	}
+}
+
+func TestFileCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cache.Put("0200|6:000001", &Entry{Created: time.Now(), Response: []byte("0210")}); err != nil {
+		t.Fatal(err)
+	}
+	if cache, err = NewFileCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok, err := cache.Get("0200|6:000001"); !ok || err != nil || string(entry.Response) != "0210" {
+		t.Errorf("entry read back after a restart as %+v %t %v", entry, ok, err)
+	}
+}
@@ -0,0 +1,407 @@
+package go8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//BerTlv is a single BER-TLV data object as found in EMV chip data. Constructed objects hold their children in Tlvs.
+type BerTlv struct {
+	Tag   int
+	Value []byte
+	Tlvs  []BerTlv
+}
+
+//IsConstructed returns true if the tag denotes a constructed data object, such as the 70 or 77 templates.
+func (t BerTlv) IsConstructed() bool {
+	return berTagConstructed(t.Tag)
+}
+
+//EmvTagNames is the dictionary of EMV tag names used when formatting messages.
+var EmvTagNames = map[int]string{
+	0x42:   "Issuer Identification Number",
+	0x4F:   "Application Identifier (AID)",
+	0x50:   "Application Label",
+	0x57:   "Track 2 Equivalent Data",
+	0x5A:   "Application PAN",
+	0x5F20: "Cardholder Name",
+	0x5F24: "Application Expiration Date",
+	0x5F25: "Application Effective Date",
+	0x5F28: "Issuer Country Code",
+	0x5F2A: "Transaction Currency Code",
+	0x5F2D: "Language Preference",
+	0x5F30: "Service Code",
+	0x5F34: "PAN Sequence Number",
+	0x5F36: "Transaction Currency Exponent",
+	0x61:   "Application Template",
+	0x6F:   "FCI Template",
+	0x70:   "READ RECORD Response Template",
+	0x71:   "Issuer Script Template 1",
+	0x72:   "Issuer Script Template 2",
+	0x77:   "Response Message Template Format 2",
+	0x80:   "Response Message Template Format 1",
+	0x82:   "Application Interchange Profile",
+	0x84:   "Dedicated File Name",
+	0x86:   "Issuer Script Command",
+	0x87:   "Application Priority Indicator",
+	0x89:   "Authorisation Code",
+	0x8A:   "Authorisation Response Code",
+	0x8C:   "CDOL1",
+	0x8D:   "CDOL2",
+	0x8E:   "CVM List",
+	0x8F:   "CA Public Key Index",
+	0x91:   "Issuer Authentication Data",
+	0x94:   "Application File Locator",
+	0x95:   "Terminal Verification Results",
+	0x9A:   "Transaction Date",
+	0x9B:   "Transaction Status Information",
+	0x9C:   "Transaction Type",
+	0x9F01: "Acquirer Identifier",
+	0x9F02: "Amount, Authorised",
+	0x9F03: "Amount, Other",
+	0x9F06: "Application Identifier (Terminal)",
+	0x9F07: "Application Usage Control",
+	0x9F08: "Application Version Number (Card)",
+	0x9F09: "Application Version Number (Terminal)",
+	0x9F0D: "IAC - Default",
+	0x9F0E: "IAC - Denial",
+	0x9F0F: "IAC - Online",
+	0x9F10: "Issuer Application Data",
+	0x9F11: "Issuer Code Table Index",
+	0x9F12: "Application Preferred Name",
+	0x9F15: "Merchant Category Code",
+	0x9F16: "Merchant Identifier",
+	0x9F1A: "Terminal Country Code",
+	0x9F1C: "Terminal Identification",
+	0x9F1E: "Interface Device Serial Number",
+	0x9F21: "Transaction Time",
+	0x9F26: "Application Cryptogram",
+	0x9F27: "Cryptogram Information Data",
+	0x9F33: "Terminal Capabilities",
+	0x9F34: "CVM Results",
+	0x9F35: "Terminal Type",
+	0x9F36: "Application Transaction Counter",
+	0x9F37: "Unpredictable Number",
+	0x9F39: "POS Entry Mode",
+	0x9F40: "Additional Terminal Capabilities",
+	0x9F41: "Transaction Sequence Counter",
+	0x9F53: "Transaction Category Code",
+	0x9F5B: "Issuer Script Results",
+	0x9F6E: "Form Factor Indicator",
+}
+
+//EmvTagName returns the dictionary name of the tag, or an empty string if the tag is unknown.
+func EmvTagName(tag int) string {
+	return EmvTagNames[tag]
+}
+
+//ParseBerTlv decodes BER-TLV data into an ordered list of data objects, descending into constructed templates.
+func ParseBerTlv(data []byte) (tlvs []BerTlv, err error) {
+	offset := 0
+	for offset < len(data) {
+		//Padding between data objects is permitted.
+		if data[offset] == 0x00 || data[offset] == 0xFF {
+			offset++
+			continue
+		}
+		var tlv BerTlv
+		var value []byte
+		offset, tlv.Tag, value, err = readBerTlv(offset, data)
+		if err != nil {
+			return tlvs, err
+		}
+		if tlv.IsConstructed() {
+			if tlv.Tlvs, err = ParseBerTlv(value); err != nil {
+				return tlvs, err
+			}
+		} else {
+			tlv.Value = value
+		}
+		tlvs = append(tlvs, tlv)
+	}
+	return tlvs, nil
+}
+
+//BuildBerTlv encodes data objects in the order given. Constructed objects are built from their children.
+func BuildBerTlv(tlvs []BerTlv) []byte {
+	buf := new(bytes.Buffer)
+	for _, tlv := range tlvs {
+		value := tlv.Value
+		if tlv.IsConstructed() {
+			value = BuildBerTlv(tlv.Tlvs)
+		}
+		writeBerTlv(buf, tlv.Tag, value)
+	}
+	return buf.Bytes()
+}
+
+func readBerTlv(offset int, data []byte) (newOffset int, tag int, value []byte, err error) {
+	tagStart := offset
+	tag = int(data[offset])
+	offset++
+	if data[tagStart]&0x1F == 0x1F {
+		//Subsequent tag bytes follow while bit 8 is set.
+		for {
+			if offset >= len(data) {
+				return offset, tag, nil, errors.New("Attempt to read passed end of data")
+			}
+			if offset-tagStart >= 4 {
+				return offset, tag, nil, errors.New(fmt.Sprint("Tag too long at offset ", tagStart))
+			}
+			b := data[offset]
+			tag = tag<<8 | int(b)
+			offset++
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+	if offset >= len(data) {
+		return offset, tag, nil, errors.New("Attempt to read passed end of data")
+	}
+	length := int(data[offset])
+	offset++
+	if length&0x80 != 0 {
+		lengthBytes := length & 0x7F
+		if lengthBytes == 0 || lengthBytes > 3 {
+			return offset, tag, nil, errors.New(fmt.Sprint("Invalid length for tag ", FormatEmvTag(tag)))
+		}
+		if offset+lengthBytes > len(data) {
+			return offset, tag, nil, errors.New("Attempt to read passed end of data")
+		}
+		length = 0
+		for _, b := range data[offset : offset+lengthBytes] {
+			length = length<<8 | int(b)
+		}
+		offset += lengthBytes
+	}
+	if offset+length > len(data) {
+		return offset, tag, nil, errors.New("Attempt to read passed end of data")
+	}
+	return offset + length, tag, data[offset : offset+length], nil
+}
+
+func writeBerTlv(buf *bytes.Buffer, tag int, value []byte) {
+	buf.Write(berTagBytes(tag))
+	length := len(value)
+	switch {
+	case length < 0x80:
+		buf.WriteByte(byte(length))
+	case length <= 0xFF:
+		buf.WriteByte(0x81)
+		buf.WriteByte(byte(length))
+	case length <= 0xFFFF:
+		buf.WriteByte(0x82)
+		buf.WriteByte(byte(length >> 8))
+		buf.WriteByte(byte(length))
+	default:
+		buf.WriteByte(0x83)
+		buf.WriteByte(byte(length >> 16))
+		buf.WriteByte(byte(length >> 8))
+		buf.WriteByte(byte(length))
+	}
+	buf.Write(value)
+}
+
+func berTagBytes(tag int) []byte {
+	var tagBytes []byte
+	for t := tag; t > 0; t >>= 8 {
+		tagBytes = append([]byte{byte(t)}, tagBytes...)
+	}
+	if len(tagBytes) == 0 {
+		tagBytes = []byte{0}
+	}
+	return tagBytes
+}
+
+func berTagConstructed(tag int) bool {
+	return berTagBytes(tag)[0]&0x20 != 0
+}
+
+//FormatEmvTag returns the tag as upper case hex, e.g. 9F26.
+func FormatEmvTag(tag int) string {
+	return fmt.Sprintf("%X", berTagBytes(tag))
+}
+
+//ParseEmvTag parses a hex tag such as 9F26.
+func ParseEmvTag(tag string) (int, error) {
+	t, err := strconv.ParseInt(tag, 16, 0)
+	if err != nil {
+		return 0, errors.New(fmt.Sprint("Invalid EMV tag ", tag))
+	}
+	return int(t), nil
+}
+
+type emvField struct {
+	*BitmapMessageField
+}
+
+//NewEmvField creates a field holding BER-TLV chip data, such as DE55. Tags are addressed as subfields by their numeric value, e.g. 0x9F26.
+func NewEmvField(fieldNumber int, name string, size int, fieldPackerUnpacker PackerUnpacker) Field {
+	return &emvField{&BitmapMessageField{fieldNumber, name, Binary, LllVar, size, fieldPackerUnpacker, nil, nil}}
+}
+
+//GetFieldDef returns a definition for the tag, named from EmvTagNames.
+func (f *emvField) GetFieldDef(tag int) (field Field, err error) {
+	return &emvTagField{&emvField{&BitmapMessageField{tag, EmvTagName(tag), Binary, LllVar, 0, NewVariableFieldPackerUnpacker(3), nil, nil}}}, nil
+}
+
+func (f *emvField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value, err = f.unpackSubFields(fieldData)
+	return newOffset, value, err
+}
+
+func (f *emvField) PackField(value FieldValue) (data []byte, err error) {
+	if value.FieldValues == nil {
+		return f.Pack([]byte(value.Value))
+	}
+	tlvData, err := f.packSubFields(value)
+	if err != nil {
+		return nil, err
+	}
+	return f.Pack(tlvData)
+}
+
+func (f *emvField) unpackSubFields(data []byte) (value FieldValue, err error) {
+	tlvs, err := ParseBerTlv(data)
+	if err != nil {
+		return value, err
+	}
+	return tlvFieldValue(tlvs), nil
+}
+
+func (f *emvField) packSubFields(value FieldValue) (data []byte, err error) {
+	return BuildBerTlv(fieldValueTlvs(value)), nil
+}
+
+//emvTagField defines a single tag within an emvField.
+type emvTagField struct {
+	*emvField
+}
+
+//FieldLabel formats the tag in hex rather than as a field number.
+func (f *emvTagField) FieldLabel() string {
+	return FormatEmvTag(f.FieldNumber)
+}
+
+//UnpackField reads the data objects of a constructed tag, or the value of a primitive one.
+func (f *emvTagField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
+	if berTagConstructed(f.FieldNumber) {
+		return f.emvField.UnpackField(offset, data)
+	}
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	return newOffset, FieldValue{Value: string(fieldData)}, err
+}
+
+func (f *emvTagField) describe(value FieldValue) string {
+	return f.Name
+}
+
+//tlvFieldValue addresses data objects by tag in FieldValues, the first of any repeated tag, and keeps them all in order
+//in Tlvs.
+func tlvFieldValue(tlvs []BerTlv) FieldValue {
+	values := make(map[int]FieldValue, len(tlvs))
+	for _, tlv := range tlvs {
+		if _, repeated := values[tlv.Tag]; repeated {
+			continue
+		}
+		if tlv.IsConstructed() {
+			values[tlv.Tag] = tlvFieldValue(tlv.Tlvs)
+		} else {
+			values[tlv.Tag] = FieldValue{Value: string(tlv.Value)}
+		}
+	}
+	return FieldValue{FieldValues: values, Tlvs: tlvs}
+}
+
+//fieldValueTlvs returns the data objects of a value in packing order: the unpacked order, taking each tag from
+//FieldValues so later changes are packed, then tags which were not unpacked in ascending order. Repeated tags are packed
+//as they were unpacked, and tags removed from FieldValues are dropped.
+func fieldValueTlvs(value FieldValue) []BerTlv {
+	tlvs := make([]BerTlv, 0, len(value.FieldValues))
+	packed := make(map[int]bool, len(value.FieldValues))
+	for _, tlv := range value.Tlvs {
+		v, ok := value.FieldValues[tlv.Tag]
+		if !ok {
+			continue
+		}
+		if packed[tlv.Tag] {
+			tlvs = append(tlvs, tlv)
+			continue
+		}
+		packed[tlv.Tag] = true
+		tlvs = append(tlvs, tagTlv(tlv.Tag, v))
+	}
+	var tags []int
+	for tag := range value.FieldValues {
+		if !packed[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Ints(tags)
+	for _, tag := range tags {
+		tlvs = append(tlvs, tagTlv(tag, value.FieldValues[tag]))
+	}
+	return tlvs
+}
+
+func tagTlv(tag int, value FieldValue) BerTlv {
+	tlv := BerTlv{Tag: tag}
+	if berTagConstructed(tag) && value.FieldValues != nil {
+		tlv.Tlvs = fieldValueTlvs(value)
+	} else {
+		tlv.Value = []byte(value.Value)
+	}
+	return tlv
+}
+
+//GetTag returns the value of an EMV tag held in a TLV field. Data objects are searched depth first in packing order, so
+//the first occurrence of a tag is found whichever template holds it.
+func (m *BitmapMessage) GetTag(fieldNr int, tag int) (value []byte, isSet bool) {
+	fieldValue, ok := m.FieldValues[fieldNr]
+	if !ok {
+		return nil, false
+	}
+	return findTag(fieldValueTlvs(fieldValue), tag)
+}
+
+func findTag(tlvs []BerTlv, tag int) ([]byte, bool) {
+	for _, tlv := range tlvs {
+		if tlv.Tag == tag {
+			return tlv.Value, true
+		}
+		if found, ok := findTag(tlv.Tlvs, tag); ok {
+			return found, ok
+		}
+	}
+	return nil, false
+}
+
+//SetTag sets an EMV tag in a TLV field, creating the field if it is not set. A field set as a raw value is parsed into
+//its data objects first, which fails if the value is not BER-TLV.
+func (m *BitmapMessage) SetTag(fieldNr int, tag int, value []byte) error {
+	if fieldValue, ok := m.FieldValues[fieldNr]; ok && fieldValue.FieldValues == nil {
+		tlvs, err := ParseBerTlv([]byte(fieldValue.Value))
+		if err != nil {
+			return errors.New(fmt.Sprint("Cannot set tag ", FormatEmvTag(tag), " in field ", fieldNr, ": ", err))
+		}
+		m.SetField(fieldNr, tlvFieldValue(tlvs))
+	}
+	m.SetSubField(fieldNr, tag, string(value))
+	return nil
+}
+
+//GetTagString returns a tag value as upper case hex.
+func (m *BitmapMessage) GetTagString(fieldNr int, tag int) (string, bool) {
+	value, ok := m.GetTag(fieldNr, tag)
+	return strings.ToUpper(hex.EncodeToString(value)), ok
+}
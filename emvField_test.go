@@ -0,0 +1,169 @@
+package go8583
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBerTlvRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		tags []int
+	}{
+		{"single byte tags", "8202580095050000008000", []int{0x82, 0x95}},
+		{"multi byte tags", "9F2608A1B2C3D4E5F607085F2A0208409F360200FF", []int{0x9F26, 0x5F2A, 0x9F36}},
+		{"three byte tag", "DF810103010203", []int{0xDF8101}},
+		{"long form length", "9F10" + "8180" + string(bytes.Repeat([]byte("AB"), 0x80)), []int{0x9F10}},
+		{"two byte length", "9F10" + "82012C" + string(bytes.Repeat([]byte("01"), 0x12C)), []int{0x9F10}},
+		{"constructed template", "7710" + "9F2701809F360200019F26040102030482021980", []int{0x77, 0x82}},
+		{"nested templates", "700D" + "6109" + "4F07A0000000031010" + "5A00", []int{0x70}},
+		{"unsorted and repeated", "9F3602000182021980" + "9F360200029F270180", []int{0x9F36, 0x82, 0x9F36, 0x9F27}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := mustHex(t, test.data)
+			tlvs, err := ParseBerTlv(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tlvs) != len(test.tags) {
+				t.Fatalf("got %d data objects, want %d", len(tlvs), len(test.tags))
+			}
+			for i, tag := range test.tags {
+				if tlvs[i].Tag != tag {
+					t.Errorf("data object %d has tag %X, want %X", i, tlvs[i].Tag, tag)
+				}
+			}
+			if built := BuildBerTlv(tlvs); !bytes.Equal(built, data) {
+				t.Errorf("built %X, want %X", built, data)
+			}
+		})
+	}
+}
+
+func TestBerTlvInvalid(t *testing.T) {
+	for _, data := range []string{"9F", "9F26", "9F2608A1B2", "9F268401000000", "5F80808001"} {
+		if _, err := ParseBerTlv(mustHex(t, data)); err == nil {
+			t.Errorf("%s parsed without error", data)
+		}
+	}
+}
+
+func emvTestTemplate() *BitmapMessageTemplate {
+	return (&BitmapMessageTemplate{Fields: CreateFields(
+		NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)),
+	)}).Freeze()
+}
+
+func TestEmvFieldRepackKeepsOrder(t *testing.T) {
+	tmpl := emvTestTemplate()
+	tlvData := mustHex(t, "9F2608A1B2C3D4E5F60708"+"82021980"+"9F360200FF"+"7005"+"9F3602000A"+"9F360200FF")
+	original := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	original.Init()
+	original.SetMsgType(0x0100)
+	original.SetField(55, FieldValue{Value: string(tlvData)})
+	packed, err := original.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	if err = BitmapUnpack(packed, tmpl, msg); err != nil {
+		t.Fatal(err)
+	}
+	repacked, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(repacked, packed) {
+		t.Errorf("repacked\n%X, want\n%X", repacked, packed)
+	}
+
+	if err = msg.SetTag(55, 0x82, []byte{0x39, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err = msg.SetTag(55, 0x95, []byte{0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	repacked, err = msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTlvs := "9F2608A1B2C3D4E5F60708" + "82023900" + "9F360200FF" + "7005" + "9F3602000A" + "9F360200FF" + "95050000000000"
+	if got := hex.EncodeToString(repacked[len("0100")+8+3:]); got != hex.EncodeToString(mustHex(t, wantTlvs)) {
+		t.Errorf("after edit got %s, want %s", got, wantTlvs)
+	}
+}
+
+func TestSetTagOnRawValue(t *testing.T) {
+	msg := &BitmapMessage{BitmapMessageTemplate: emvTestTemplate()}
+	msg.Init()
+	msg.SetMsgType(0x0100)
+	msg.SetString(55, string(mustHex(t, "82021980"+"9F360200FF")))
+	if err := msg.SetTag(55, 0x9F36, []byte{0x01, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.SetTag(55, 0x95, []byte{0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTlvs := "82021980" + "9F36020100" + "95050000000000"
+	if got := hex.EncodeToString(packed[len("0100")+8+3:]); got != hex.EncodeToString(mustHex(t, wantTlvs)) {
+		t.Errorf("got %s, want %s", got, wantTlvs)
+	}
+
+	msg.SetString(55, "\x9F")
+	if err := msg.SetTag(55, 0x9F36, []byte{0x01, 0x00}); err == nil {
+		t.Error("set a tag in a value which is not BER-TLV")
+	}
+	if value, _ := msg.GetField(55); value != "\x9F" {
+		t.Errorf("failed SetTag changed the value to %X", value)
+	}
+}
+
+func TestGetTagDepthFirst(t *testing.T) {
+	msg := &BitmapMessage{BitmapMessageTemplate: emvTestTemplate()}
+	msg.Init()
+	tlvs, err := ParseBerTlv(mustHex(t, "7005"+"9F36020001"+"9F36020002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.SetField(55, tlvFieldValue(tlvs))
+	for i := 0; i < 20; i++ {
+		if value, ok := msg.GetTagString(55, 0x9F36); !ok || value != "0001" {
+			t.Fatalf("got %s %v, want the first occurrence 0001", value, ok)
+		}
+	}
+	if _, ok := msg.GetTag(55, 0x9F26); ok {
+		t.Error("found a tag which is not set")
+	}
+}
+
+func TestEmvTagDefinition(t *testing.T) {
+	field := NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)).(MessageTemplate)
+	def, err := field.GetFieldDef(0x9F26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := def.PackField(FieldValue{Value: "\x01\x02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, value, err := def.UnpackField(0, data); err != nil || value.Value != "\x01\x02" {
+		t.Errorf("unpacked %X %v, want 0102", value.Value, err)
+	}
+}
@@ -2,11 +2,9 @@ package main
 
 import (
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/doswell/go8583"
-	"github.com/doswell/go8583/util"
 )
 
 func main() {
@@ -14,13 +12,25 @@ func main() {
 	//Create an empty new field
 	message := NewIso8583Message()
 	message.SetString(2, "2342434232")
-	message.SetString(3, "002000")
+	if err := message.SetProcessingCode(go8583.ProcessingCode{TransactionType: go8583.Purchase, From: go8583.ChequeAccount, To: go8583.DefaultAccount}); err != nil {
+		fmt.Println("Invalid processing code:", err)
+	}
+	if err := message.SetPosEntryMode(go8583.PosEntryMode{PanEntryMode: go8583.PanEntryChip, PinCapability: go8583.PinCapable}); err != nil {
+		fmt.Println("Invalid POS entry mode:", err)
+	}
+	message.SetPosConditionCode(go8583.PosConditionNormal)
 
 	pan, ok := message.GetString(2)
 	if ok {
 		fmt.Println("Recorded pan is", pan)
 	}
 
+	message.SetMsgType(0x0200)
+	if err := message.Validate(); err != nil {
+		fmt.Println("Invalid message:", err)
+	}
+	fmt.Print(message)
+
 }
 
 //Iso8583 Example standard Is8583 message layout per
@@ -33,26 +43,26 @@ var iso8583MsgTemplate = (&go8583.BitmapMessageTemplate{
 	Fields: go8583.CreateFields(
 		go8583.NewFixedField(1, "extendedBitMap", 8, go8583.Binary),
 		go8583.NewLlVarField(2, "pan", 2, go8583.AlphaNumeric),
-		go8583.NewFixedField(3, "processingCode", 6, go8583.Numeric),
+		go8583.NewProcessingCodeField(3, "processingCode"),
 		go8583.NewFixedField(4, "amountTransaction", 12, go8583.Numeric),
 		go8583.NewFixedField(5, "amountSettlement", 12, go8583.Numeric),
 		go8583.NewFixedField(6, "amountcardholderBilling", 12, go8583.Numeric),
-		go8583.NewFixedField(7, "transmissionDateTime", 10, go8583.Numeric),
+		go8583.NewDateTimeField(7, "transmissionDateTime", go8583.LayoutMMDDhhmmss, time.UTC),
 		go8583.NewFixedField(9, "conversionRateSettlement", 8, go8583.Numeric),
 		go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
-		go8583.NewFixedField(12, "localTranTime", 6, go8583.Numeric),
-		go8583.NewFixedField(13, "localTranDate", 4, go8583.Numeric),
+		go8583.NewDateTimeField(12, "localTranTime", go8583.Layouthhmmss, nil),
+		go8583.NewDateTimeField(13, "localTranDate", go8583.LayoutMMDD, nil),
 		go8583.NewFixedField(14, "expirationDate", 4, go8583.Numeric),
-		go8583.NewFixedField(15, "settlementDate", 4, go8583.Numeric),
+		go8583.NewDateTimeField(15, "settlementDate", go8583.LayoutMMDD, nil),
 		go8583.NewFixedField(18, "merchantType", 4, go8583.Numeric),
-		go8583.NewFixedField(22, "posEntryMode", 3, go8583.Numeric),
+		go8583.NewPosEntryModeField(22, "posEntryMode"),
 		go8583.NewFixedField(23, "cardSequenceNumber", 3, go8583.Numeric),
-		go8583.NewFixedField(25, "posConditionCode", 2, go8583.Numeric),
+		go8583.NewPosConditionCodeField(25, "posConditionCode"),
 		go8583.NewFixedField(26, "posPinCaptureCode", 2, go8583.Numeric),
 		go8583.NewFixedField(28, "tranFee", 9, go8583.AlphaNumeric),
 		go8583.NewFixedField(30, "settleFee", 9, go8583.AlphaNumeric),
 		go8583.NewLlVarField(32, "acquiingInstId", 11, go8583.Numeric),
-		go8583.NewLlVarField(35, "track2", 37, go8583.AlphaNumericSpecial),
+		go8583.NewTrack2Field(35, "track2", go8583.NewVariableFieldPackerUnpacker(2)),
 		go8583.NewFixedField(37, "retrievalReferneceNumber", 12, go8583.AlphaNumericSpecial),
 		go8583.NewFixedField(38, "authoizationCode", 6, go8583.AlphaNumericSpecial),
 		go8583.NewFixedField(39, "responseCode", 2, go8583.AlphaNumeric),
@@ -60,23 +70,42 @@ var iso8583MsgTemplate = (&go8583.BitmapMessageTemplate{
 
 		go8583.NewFixedField(41, "terminalId", 8, go8583.AlphaNumericSpecial),
 		go8583.NewFixedField(42, "cardAcceptorId", 15, go8583.AlphaNumericSpecial),
-		go8583.NewFixedField(43, "cardAcceptorNameLoc", 40, go8583.AlphaNumericSpecial),
+		go8583.NewFixedPositionalField(43, "cardAcceptorNameLoc", []go8583.Field{
+			go8583.NewFixedField(1, "nameLocation", 23, go8583.AlphaNumericSpecial),
+			go8583.NewFixedField(2, "city", 13, go8583.AlphaNumericSpecial),
+			go8583.NewFixedField(3, "state", 2, go8583.Alpha),
+			go8583.NewFixedField(4, "country", 2, go8583.Alpha),
+		}),
 		//   [LLVAR  ans  ..25 003] 044 [018]
 		go8583.NewLlVarField(44, "additionalRspData", 25, go8583.AlphaNumericSpecial),
 		go8583.NewLllVarField(48, "additionalData", 999, go8583.AlphaNumericSpecial),
 		go8583.NewFixedField(49, "currencyCodeTran", 3, go8583.Numeric),
+		go8583.NewFixedBinaryField(52, "pinBlock", 64),
 		go8583.NewLllVarField(54, "extendedAmounts", 120, go8583.AlphaNumeric),
-		go8583.NewLllVarField(55, "iccData", 300, go8583.Binary),
+		go8583.NewEmvField(55, "iccData", 300, go8583.NewVariableFieldPackerUnpacker(3)),
 		go8583.NewLllVarField(57, "authorizationLifecycleCode", 3, go8583.Numeric),
 		go8583.NewLllVarField(59, "echoData", 500, go8583.AlphaNumericSpecial),
+		go8583.NewFixedBinaryField(64, "mac", 64),
 		go8583.NewFixedField(70, "networkMgmtCode", 3, go8583.Numeric),
-		go8583.NewFixedField(90, "originalDataElements", 42, go8583.AlphaNumeric),
+		go8583.NewFixedPositionalField(90, "originalDataElements", []go8583.Field{
+			go8583.NewFixedField(1, "originalMsgType", 4, go8583.Numeric),
+			go8583.NewFixedField(2, "originalTraceNumber", 6, go8583.Numeric),
+			go8583.NewFixedField(3, "originalTransmissionDateTime", 10, go8583.Numeric),
+			go8583.NewFixedField(4, "originalAcquiringInstId", 11, go8583.Numeric),
+			go8583.NewFixedField(5, "originalForwardingInstId", 11, go8583.Numeric),
+		}),
 		go8583.NewFixedField(91, "fileUpdateCode", 1, go8583.AlphaNumeric),
 		// [Fixed  an*    42 042] 095 [000000010000000000010000C00000000C00000000]
-		go8583.NewFixedField(95, "replacementAmounts", 42, go8583.AlphaNumeric),
+		go8583.NewFixedPositionalField(95, "replacementAmounts", []go8583.Field{
+			go8583.NewFixedField(1, "actualAmountTransaction", 12, go8583.Numeric),
+			go8583.NewFixedField(2, "actualAmountSettlement", 12, go8583.Numeric),
+			go8583.NewFixedField(3, "actualTranFee", 9, go8583.AlphaNumeric),
+			go8583.NewFixedField(4, "actualSettleFee", 9, go8583.AlphaNumeric),
+		}),
 		go8583.NewLlVarField(100, "receivingInstId", 11, go8583.Numeric),
 		go8583.NewLlVarField(101, "fileName", 17, go8583.AlphaNumeric),
 		go8583.NewLllVarField(123, "customField", 999, go8583.AlphaNumeric),
+		go8583.NewFixedBinaryField(128, "secondaryMac", 64),
 
 		//Custom field with field presence denoted by a bitmap field.
 		go8583.NewBitmapField(127, "customBitmapSubFields",
@@ -92,6 +121,30 @@ var iso8583MsgTemplate = (&go8583.BitmapMessageTemplate{
 	),
 })
 
+func init() {
+	iso8583MsgTemplate.AddRules(0x0200,
+		go8583.ConditionalField(2, go8583.FieldIsNotSet(35)),
+		go8583.MandatoryField(3),
+		go8583.MandatoryField(4),
+		go8583.MandatoryField(7),
+		go8583.MandatoryField(11),
+		go8583.ConditionalField(14, go8583.FieldIsSet(2)),
+		go8583.MandatoryField(41),
+		go8583.MandatoryField(49),
+	)
+	iso8583MsgTemplate.AddRules(0x0800,
+		go8583.MandatoryField(7),
+		go8583.MandatoryField(11),
+		go8583.MandatoryField(70),
+		go8583.NotAllowedField(2),
+	)
+	iso8583MsgTemplate.AddValidator(go8583.PanLuhnValidator)
+	stan, _ := go8583.NewStanGenerator(go8583.NewMemoryCounterStore())
+	iso8583MsgTemplate.SetAutoFill(11, stan)
+	iso8583MsgTemplate.SetAutoFillTime(7, 12, 13)
+	iso8583MsgTemplate.Freeze()
+}
+
 func NewIso8583Message() *Iso8583 {
 	msg := new(Iso8583)
 	msg.BitmapMessage = new(go8583.BitmapMessage)
@@ -107,13 +160,10 @@ func NewIso8583MessageFromByte(data []byte) (*Iso8583, error) {
 	return msg, err
 }
 
-func NewIso8583_0800Message(ntwkCode string, trace int) *Iso8583 {
+//NewIso8583_0800Message creates a network management message, assigning the trace number and transmission time.
+func NewIso8583_0800Message(ntwkCode string) (*Iso8583, error) {
 	msg := NewIso8583Message()
 	msg.SetMsgType(0x0800)
-	msg.SetString(7, time.Now().UTC().Format("0102150405"))
-	msg.SetString(11, util.LeftPad2Len(strconv.Itoa(trace), "0", 6))
-	msg.SetString(12, time.Now().Format("150405"))
-	msg.SetString(13, time.Now().Format("0102"))
 	msg.SetString(70, ntwkCode)
-	return msg
+	return msg, msg.ApplyAutoFill()
 }
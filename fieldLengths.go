@@ -12,3 +12,16 @@ const (
 	LllllVar
 	LlllllVar
 )
+
+//packerLength returns the length type of fields packed by a packer: Fixed, or the variable length of its length prefix.
+func packerLength(packerUnpacker PackerUnpacker) variableFieldLength {
+	switch p := packerUnpacker.(type) {
+	case *fixedField:
+		return Fixed
+	case *variableField:
+		return variableFieldLength(p.varLength)
+	case *hexField:
+		return packerLength(p.packerUnpacker)
+	}
+	return LlVar
+}
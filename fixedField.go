@@ -26,8 +26,16 @@ func (f *fixedField) Pack(fieldData []byte) (data []byte, err error) {
 	return fieldData, nil
 }
 
+//Append writes the field data, which must already be the size of the field.
+func (f *fixedField) Append(dst []byte, fieldData string) ([]byte, error) {
+	if len(fieldData) != f.Size {
+		return dst, errors.New("Field data size not equal to total field size for a fixed field.")
+	}
+	return append(dst, fieldData...), nil
+}
+
 func NewFixedField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, Fixed, size, NewFixedFieldPackerUnpacker(size), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, Fixed, size, NewFixedFieldPackerUnpacker(size), nil, nil}
 }
 
 func NewFixedFieldPackerUnpacker(size int) PackerUnpacker {
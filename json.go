@@ -0,0 +1,126 @@
+package go8583
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type jsonMessage struct {
+	MsgType string                     `json:"mti"`
+	Fields  map[string]json.RawMessage `json:"fields"`
+}
+
+//MarshalJSON encodes the message with fields keyed by number, composite fields as objects and binary fields as hex, e.g.
+//	{"mti":"0200","fields":{"3":"000000","55":{"9F26":"1122334455667788"}}}
+func (m *BitmapMessage) MarshalJSON() ([]byte, error) {
+	fields, err := fieldsToJSON(m.BitmapMessageTemplate, nil, m.FieldValues)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMessage{MsgType: m.GetMsgTypeString(), Fields: fields})
+}
+
+func fieldsToJSON(tmpl MessageTemplate, formatter subFieldFormatter, values map[int]FieldValue) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage, len(values))
+	for fieldNr, value := range values {
+		var field Field
+		if tmpl != nil {
+			field, _ = tmpl.GetFieldDef(fieldNr)
+		}
+		key := strconv.Itoa(fieldNr)
+		if formatter != nil {
+			key = formatter.FormatSubField(fieldNr)
+		}
+		var encoded []byte
+		var err error
+		if value.FieldValues != nil {
+			subTmpl, _ := field.(MessageTemplate)
+			subFormatter, _ := field.(subFieldFormatter)
+			var subFields map[string]json.RawMessage
+			if subFields, err = fieldsToJSON(subTmpl, subFormatter, value.FieldValues); err != nil {
+				return nil, err
+			}
+			encoded, err = json.Marshal(subFields)
+		} else {
+			encoded, err = json.Marshal(displayValue(field, value))
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = encoded
+	}
+	return fields, nil
+}
+
+//UnmarshalJSON decodes a message encoded by MarshalJSON. The message must already have its template.
+func (m *BitmapMessage) UnmarshalJSON(data []byte) error {
+	if m.BitmapMessageTemplate == nil {
+		return errors.New("Message must have a template to decode JSON")
+	}
+	var decoded jsonMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	msgType, err := strconv.ParseInt(decoded.MsgType, 16, 0)
+	if err != nil {
+		return errors.New(fmt.Sprint("Message type not numeric; ", err))
+	}
+	values, err := fieldsFromJSON(m.BitmapMessageTemplate, nil, decoded.Fields)
+	if err != nil {
+		return err
+	}
+	m.Reset()
+	m.SetMsgType(int(msgType))
+	for fieldNr, value := range values {
+		m.SetField(fieldNr, value)
+	}
+	return nil
+}
+
+func fieldsFromJSON(tmpl MessageTemplate, parser subFieldParser, fields map[string]json.RawMessage) (map[int]FieldValue, error) {
+	values := make(map[int]FieldValue, len(fields))
+	for key, encoded := range fields {
+		var fieldNr int
+		var err error
+		if parser != nil {
+			fieldNr, err = parser.ParseSubField(key)
+		} else {
+			fieldNr, err = strconv.Atoi(key)
+		}
+		if err != nil {
+			return nil, errors.New(fmt.Sprint("Invalid field ", key))
+		}
+		var field Field
+		if tmpl != nil {
+			field, _ = tmpl.GetFieldDef(fieldNr)
+		}
+
+		var s string
+		if err = json.Unmarshal(encoded, &s); err == nil {
+			if field != nil && field.GetType() == Binary {
+				data, err := hex.DecodeString(s)
+				if err != nil {
+					return nil, errors.New(fmt.Sprint("Invalid hex for field ", key, ": ", err))
+				}
+				s = string(data)
+			}
+			values[fieldNr] = FieldValue{Value: s}
+			continue
+		}
+		var subFields map[string]json.RawMessage
+		if err = json.Unmarshal(encoded, &subFields); err != nil {
+			return nil, errors.New(fmt.Sprint("Field ", key, " must be a string or object"))
+		}
+		subTmpl, _ := field.(MessageTemplate)
+		subParser, _ := field.(subFieldParser)
+		subValues, err := fieldsFromJSON(subTmpl, subParser, subFields)
+		if err != nil {
+			return nil, err
+		}
+		values[fieldNr] = FieldValue{FieldValues: subValues}
+	}
+	return values, nil
+}
@@ -0,0 +1,81 @@
+package go8583
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func jsonTestTemplate() *BitmapMessageTemplate {
+	return (&BitmapMessageTemplate{Fields: CreateFields(
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil),
+		NewFixedHexField(52, "pinData", 8),
+		NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)),
+	)}).Freeze()
+}
+
+const jsonTestMessage = `{"mti":"0200","fields":{"3":"000000","48":{"01":"ABCD"},"52":"0123456789ABCDEF","55":{"82":"1980","9F26":"A1B2C3D4E5F60708"}}}`
+
+func TestMarshalJSON(t *testing.T) {
+	tmpl := jsonTestTemplate()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(3, "000000")
+	msg.SetSubField(48, 1, "ABCD")
+	if err := msg.SetHex(52, "0123456789ABCDEF"); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.SetTag(55, 0x9F26, mustHex(t, "A1B2C3D4E5F60708")); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.SetTag(55, 0x82, mustHex(t, "1980")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != jsonTestMessage {
+		t.Errorf("got\n%s, want\n%s", data, jsonTestMessage)
+	}
+
+	decoded := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	decoded.Init()
+	decoded.SetString(4, "000000001000")
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IsFieldSet(4) {
+		t.Error("decoding kept a field set before")
+	}
+	want, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packed, err := decoded.Pack(); err != nil || string(packed) != string(want) {
+		t.Errorf("decoded message packed %X %v, want %X", packed, err, want)
+	}
+}
+
+func TestUnmarshalJSONInvalid(t *testing.T) {
+	tests := map[string]string{
+		"message type":              `{"mti":"02X0","fields":{}}`,
+		"hex":                       `{"mti":"0200","fields":{"52":"0123456789ABCDEZ"}}`,
+		"odd length hex":            `{"mti":"0200","fields":{"52":"ABC"}}`,
+		"field number":              `{"mti":"0200","fields":{"three":"000000"}}`,
+		"tag":                       `{"mti":"0200","fields":{"55":{"XYZ":"00"}}}`,
+		"neither string nor object": `{"mti":"0200","fields":{"3":123}}`,
+		"not an object":             `[]`,
+	}
+	for name, data := range tests {
+		msg := &BitmapMessage{BitmapMessageTemplate: jsonTestTemplate()}
+		msg.Init()
+		if err := json.Unmarshal([]byte(data), msg); err == nil {
+			t.Errorf("%s: decoded %s", name, data)
+		}
+	}
+	if err := json.Unmarshal([]byte(jsonTestMessage), &BitmapMessage{}); err == nil {
+		t.Error("decoded a message without a template")
+	}
+}
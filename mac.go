@@ -0,0 +1,146 @@
+package go8583
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583/mac"
+)
+
+//ErrInvalidMac is returned by Mac.Verify when the MAC of a message does not match.
+var ErrInvalidMac = errors.New("MAC verification failed")
+
+//Mac computes and verifies the MAC carried in the last field of a message, DE64, or DE128 when the secondary bitmap is present.
+type Mac struct {
+	Provider mac.Provider
+	//Fields lists the fields the MAC covers, in order, where field 0 is the message type. When empty the MAC covers the packed
+	//message up to the MAC field.
+	Fields []int
+}
+
+//MacFieldNumber returns the field which carries the MAC of msg, the last field of its last bitmap.
+func MacFieldNumber(msg *BitmapMessage) int {
+	macFieldNr := 64
+	if msg.ForceSecondaryBitmap {
+		macFieldNr = 128
+	}
+	for fieldNr := range msg.FieldValues {
+		switch {
+		case fieldNr == 64 || fieldNr == 128:
+		case fieldNr > 64:
+			macFieldNr = 128
+		}
+	}
+	return macFieldNr
+}
+
+//Generate sets the MAC field of msg and returns the packed message. Any MAC already in the message is replaced, and the
+//MAC field is left unset if the MAC cannot be generated.
+func (m *Mac) Generate(msg *BitmapMessage) (data []byte, err error) {
+	msg.UnsetField(64)
+	msg.UnsetField(128)
+	macFieldNr := MacFieldNumber(msg)
+	field, err := msg.GetFieldDef(macFieldNr)
+	if err != nil {
+		return nil, errors.New(fmt.Sprint("MAC field ", macFieldNr, " is not in the template"))
+	}
+
+	//Pack with a placeholder so the bitmap includes the MAC field.
+	placeholder := FieldValue{Value: string(make([]byte, field.GetSize()))}
+	msg.SetField(macFieldNr, placeholder)
+	defer func() {
+		if err != nil {
+			msg.UnsetField(macFieldNr)
+		}
+	}()
+	data, err = msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+	packedPlaceholder, err := field.PackField(placeholder)
+	if err != nil {
+		return nil, err
+	}
+	data = data[:len(data)-len(packedPlaceholder)]
+
+	macData, err := m.macData(msg, data)
+	if err != nil {
+		return nil, err
+	}
+	code, err := m.Provider.Generate(macData)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) < field.GetSize() {
+		return nil, errors.New(fmt.Sprint("MAC is shorter than field ", macFieldNr))
+	}
+	value := FieldValue{Value: string(code[:field.GetSize()])}
+	packedMac, err := field.PackField(value)
+	if err != nil {
+		return nil, err
+	}
+	msg.SetField(macFieldNr, value)
+	return append(data, packedMac...), nil
+}
+
+//Verify checks the MAC of msg, which was unpacked from data by BitmapUnpack. ErrInvalidMac is returned if the MAC does not match.
+func (m *Mac) Verify(msg *BitmapMessage, data []byte) error {
+	macFieldNr := MacFieldNumber(msg)
+	value, isSet := msg.FieldValues[macFieldNr]
+	if !isSet {
+		return errors.New(fmt.Sprint("MAC field ", macFieldNr, " is not set"))
+	}
+	field, err := msg.GetFieldDef(macFieldNr)
+	if err != nil {
+		return errors.New(fmt.Sprint("MAC field ", macFieldNr, " is not in the template"))
+	}
+	packedMac, err := field.PackField(value)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(data, packedMac) {
+		return errors.New(fmt.Sprint("MAC field ", macFieldNr, " is not the last field of the message"))
+	}
+
+	macData, err := m.macData(msg, data[:len(data)-len(packedMac)])
+	if err != nil {
+		return err
+	}
+	valid, err := mac.Verify(m.Provider, macData, []byte(value.Value))
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidMac
+	}
+	return nil
+}
+
+//macData returns the data the MAC covers, given the packed message up to the MAC field.
+func (m *Mac) macData(msg *BitmapMessage, data []byte) ([]byte, error) {
+	if len(m.Fields) == 0 {
+		return data, nil
+	}
+	var macData []byte
+	for _, fieldNr := range m.Fields {
+		if fieldNr == 0 {
+			macData = appendMsgType(macData, msg.MessageType)
+			continue
+		}
+		value, isSet := msg.FieldValues[fieldNr]
+		if !isSet {
+			continue
+		}
+		field, err := msg.GetFieldDef(fieldNr)
+		if err != nil {
+			return nil, err
+		}
+		fieldData, err := field.PackField(value)
+		if err != nil {
+			return nil, err
+		}
+		macData = append(macData, fieldData...)
+	}
+	return macData, nil
+}
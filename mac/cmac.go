@@ -0,0 +1,66 @@
+package mac
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte
+}
+
+//NewAesCmac creates an AES-CMAC provider (NIST SP 800-38B, RFC 4493). The key is 16, 24 or 32 bytes.
+func NewAesCmac(key []byte) (Provider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+	k1 := shiftSubkey(l)
+	k2 := shiftSubkey(k1)
+	return &cmac{block, k1, k2}, nil
+}
+
+//shiftSubkey derives a CMAC subkey, shifting left by one bit and applying the block polynomial on overflow.
+func shiftSubkey(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	if carry == 1 {
+		out[len(out)-1] ^= 0x87
+	}
+	return out
+}
+
+func (p *cmac) Generate(data []byte) ([]byte, error) {
+	blockSize := p.block.BlockSize()
+	blocks := (len(data) + blockSize - 1) / blockSize
+	complete := blocks > 0 && len(data)%blockSize == 0
+	if blocks == 0 {
+		blocks = 1
+	}
+
+	//The last block is masked with K1 if complete, otherwise padded with method 2 and masked with K2.
+	last := make([]byte, blockSize)
+	copy(last, data[(blocks-1)*blockSize:])
+	subkey := p.k1
+	if !complete {
+		last[len(data)-(blocks-1)*blockSize] = 0x80
+		subkey = p.k2
+	}
+	for i := range last {
+		last[i] ^= subkey[i]
+	}
+
+	mac := cbcMac(p.block, data[:(blocks-1)*blockSize])
+	for i := range mac {
+		mac[i] ^= last[i]
+	}
+	p.block.Encrypt(mac, mac)
+	return mac, nil
+}
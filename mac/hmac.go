@@ -0,0 +1,22 @@
+package mac
+
+import (
+	"crypto/hmac"
+	"hash"
+)
+
+type hmacProvider struct {
+	hash func() hash.Hash
+	key  []byte
+}
+
+//NewHmac creates an HMAC provider using the given hash, such as sha256.New.
+func NewHmac(hash func() hash.Hash, key []byte) Provider {
+	return &hmacProvider{hash, append([]byte{}, key...)}
+}
+
+func (p *hmacProvider) Generate(data []byte) ([]byte, error) {
+	h := hmac.New(p.hash, p.key)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
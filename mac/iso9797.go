@@ -0,0 +1,94 @@
+package mac
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"errors"
+)
+
+type iso9797Alg1 struct {
+	block   cipher.Block
+	padding Padding
+}
+
+//NewIso9797Alg1 creates a CBC-MAC provider, ISO 9797-1 algorithm 1. An 8 byte key gives single DES (ANSI X9.9),
+//a 16 or 24 byte key gives triple DES.
+func NewIso9797Alg1(key []byte, padding Padding) (Provider, error) {
+	var block cipher.Block
+	var err error
+	switch len(key) {
+	case 8:
+		block, err = des.NewCipher(key)
+	case 16:
+		block, err = des.NewTripleDESCipher(append(append([]byte{}, key...), key[:8]...))
+	case 24:
+		block, err = des.NewTripleDESCipher(key)
+	default:
+		return nil, errors.New("Key must be 8, 16 or 24 bytes")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &iso9797Alg1{block, padding}, nil
+}
+
+func (p *iso9797Alg1) Generate(data []byte) ([]byte, error) {
+	padded, err := p.padding.pad(data, p.block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	return cbcMac(p.block, padded), nil
+}
+
+type iso9797Alg3 struct {
+	first   cipher.Block
+	second  cipher.Block
+	final   cipher.Block
+	padding Padding
+}
+
+//NewIso9797Alg3 creates a retail MAC provider, ISO 9797-1 algorithm 3 (ANSI X9.19). The data is chained with single DES under the
+//first key, and the last block is decrypted under the second key and encrypted under the third, or the first for a 16 byte key.
+func NewIso9797Alg3(key []byte, padding Padding) (Provider, error) {
+	if len(key) != 16 && len(key) != 24 {
+		return nil, errors.New("Key must be 16 or 24 bytes")
+	}
+	first, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, err
+	}
+	second, err := des.NewCipher(key[8:16])
+	if err != nil {
+		return nil, err
+	}
+	final := first
+	if len(key) == 24 {
+		if final, err = des.NewCipher(key[16:]); err != nil {
+			return nil, err
+		}
+	}
+	return &iso9797Alg3{first, second, final, padding}, nil
+}
+
+func (p *iso9797Alg3) Generate(data []byte) ([]byte, error) {
+	padded, err := p.padding.pad(data, des.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	mac := cbcMac(p.first, padded)
+	p.second.Decrypt(mac, mac)
+	p.final.Encrypt(mac, mac)
+	return mac, nil
+}
+
+//cbcMac returns the last block of data encrypted in CBC mode with a zero IV. data must be a whole number of blocks.
+func cbcMac(block cipher.Block, data []byte) []byte {
+	mac := make([]byte, block.BlockSize())
+	for offset := 0; offset < len(data); offset += len(mac) {
+		for i := range mac {
+			mac[i] ^= data[offset+i]
+		}
+		block.Encrypt(mac, mac)
+	}
+	return mac
+}
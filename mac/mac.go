@@ -0,0 +1,62 @@
+//Package mac generates and verifies message authentication codes, ISO 9797-1 algorithms 1 and 3 (ANSI X9.9 and X9.19),
+//AES-CMAC and HMAC. Keys are held by a Provider, so the MAC can be computed in software or by an HSM.
+package mac
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+//Provider computes a MAC over data. Implement it to have an HSM compute the MAC without the key leaving it.
+type Provider interface {
+	Generate(data []byte) ([]byte, error)
+}
+
+//Verifier is implemented by providers which verify a MAC themselves, as HSMs usually do.
+type Verifier interface {
+	Verify(data []byte, mac []byte) (bool, error)
+}
+
+//Verify checks mac against data. The mac may be truncated, only its length is compared. Providers implementing Verifier
+//verify the MAC themselves.
+func Verify(provider Provider, data []byte, mac []byte) (bool, error) {
+	if verifier, ok := provider.(Verifier); ok {
+		return verifier.Verify(data, mac)
+	}
+	expected, err := provider.Generate(data)
+	if err != nil {
+		return false, err
+	}
+	if len(mac) == 0 || len(mac) > len(expected) {
+		return false, errors.New("Invalid MAC length")
+	}
+	return subtle.ConstantTimeCompare(expected[:len(mac)], mac) == 1, nil
+}
+
+//Padding is the ISO 9797-1 padding method applied before a block cipher MAC.
+type Padding int
+
+const (
+	//PaddingMethod1 pads with zero bytes to a whole block, as in ANSI X9.9 and X9.19.
+	PaddingMethod1 Padding = iota + 1
+	//PaddingMethod2 appends 0x80 then zero bytes to a whole block.
+	PaddingMethod2
+)
+
+func (p Padding) pad(data []byte, blockSize int) ([]byte, error) {
+	switch p {
+	case PaddingMethod1:
+		if len(data) > 0 && len(data)%blockSize == 0 {
+			return data, nil
+		}
+		padded := make([]byte, (len(data)/blockSize+1)*blockSize)
+		copy(padded, data)
+		return padded, nil
+	case PaddingMethod2:
+		padded := make([]byte, (len(data)/blockSize+1)*blockSize)
+		copy(padded, data)
+		padded[len(data)] = 0x80
+		return padded, nil
+	}
+	return nil, errors.New("Unknown padding method")
+}
@@ -0,0 +1,97 @@
+package mac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestProviders(t *testing.T) {
+	rfc4493Key := "2B7E151628AED2A6ABF7158809CF4F3C"
+	rfc4493Message := "6BC1BEE22E409F96E93D7E117393172AAE2D8A571E03AC9C9EB76FAC45AF8E5130C81C46A35CE411E5FBC1191A0A52EFF69F2445DF4F9B17AD2B417BE66C3710"
+	tests := []struct {
+		name     string
+		provider func(key []byte) (Provider, error)
+		key      string
+		data     string
+		mac      string
+	}{
+		//FIPS 113 / ANSI X9.9, "7654321 Now is the time for ".
+		{"iso 9797 alg 1 des", func(key []byte) (Provider, error) { return NewIso9797Alg1(key, PaddingMethod1) },
+			"0123456789ABCDEF", "37363534333231204E6F77206973207468652074696D6520666F7220", "F1D30F6849312CA4"},
+		//ANSI X9.19, "Now is the time for all ".
+		{"iso 9797 alg 3", func(key []byte) (Provider, error) { return NewIso9797Alg3(key, PaddingMethod1) },
+			"0123456789ABCDEFFEDCBA9876543210", "4E6F77206973207468652074696D6520666F7220616C6C20", "A1C72E74EA3FA9B6"},
+		//RFC 4493 examples 1 to 4.
+		{"cmac empty", NewAesCmac, rfc4493Key, "", "BB1D6929E95937287FA37D129B756746"},
+		{"cmac one block", NewAesCmac, rfc4493Key, rfc4493Message[:32], "070A16B46B4D4144F79BDD9DD04A287C"},
+		{"cmac partial block", NewAesCmac, rfc4493Key, rfc4493Message[:80], "DFA66747DE9AE63030CA32611497C827"},
+		{"cmac four blocks", NewAesCmac, rfc4493Key, rfc4493Message, "51F0BEBF7E3B9D92FC49741779363CFE"},
+		//RFC 4231 test cases 1 and 2.
+		{"hmac sha256", func(key []byte) (Provider, error) { return NewHmac(sha256.New, key), nil },
+			strings.Repeat("0B", 20), hex.EncodeToString([]byte("Hi There")),
+			"B0344C61D8DB38535CA8AFCEAF0BF12B881DC200C9833DA726E9376C2E32CFF7"},
+		{"hmac sha256 short key", func(key []byte) (Provider, error) { return NewHmac(sha256.New, key), nil },
+			hex.EncodeToString([]byte("Jefe")), hex.EncodeToString([]byte("what do ya want for nothing?")),
+			"5BDCC146BF60754E6A042426089575C75A003F089D2739839DEC58B964EC3843"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, err := test.provider(mustHex(t, test.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := mustHex(t, test.data)
+			mac, err := provider.Generate(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.ToUpper(hex.EncodeToString(mac)); got != test.mac {
+				t.Errorf("got %s, want %s", got, test.mac)
+			}
+			if ok, err := Verify(provider, data, mustHex(t, test.mac)[:4]); !ok || err != nil {
+				t.Errorf("truncated MAC not verified: %v", err)
+			}
+			mac[0] ^= 1
+			if ok, _ := Verify(provider, data, mac); ok {
+				t.Error("altered MAC verified")
+			}
+		})
+	}
+}
+
+func TestPadding(t *testing.T) {
+	tests := []struct {
+		padding Padding
+		data    string
+		want    string
+	}{
+		{PaddingMethod1, "", "0000000000000000"},
+		{PaddingMethod1, "01", "0100000000000000"},
+		{PaddingMethod1, "0102030405060708", "0102030405060708"},
+		{PaddingMethod2, "01", "0180000000000000"},
+		{PaddingMethod2, "0102030405060708", "01020304050607088000000000000000"},
+	}
+	for _, test := range tests {
+		padded, err := test.padding.pad(mustHex(t, test.data), 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.ToUpper(hex.EncodeToString(padded)); got != test.want {
+			t.Errorf("method %d padded %s to %s, want %s", test.padding, test.data, got, test.want)
+		}
+	}
+	if _, err := Padding(0).pad(nil, 8); err == nil {
+		t.Error("unknown padding method padded without error")
+	}
+}
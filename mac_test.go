@@ -0,0 +1,133 @@
+package go8583
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/doswell/go8583/mac"
+)
+
+func macTestMessage(t *testing.T) *BitmapMessage {
+	t.Helper()
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewFixedBinaryField(64, "mac", 64),
+		NewLlVarField(100, "receivingInstId", 11, Numeric),
+		NewFixedBinaryField(128, "secondaryMac", 64),
+	)}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(2, "4012345678909")
+	msg.SetString(3, "000000")
+	msg.SetString(11, "000001")
+	return msg
+}
+
+func macTestProvider(t *testing.T) mac.Provider {
+	t.Helper()
+	provider, err := mac.NewIso9797Alg3(mustHex(t, "0123456789ABCDEFFEDCBA9876543210"), mac.PaddingMethod1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestMacRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     []int
+		secondary  bool
+		macFieldNr int
+	}{
+		{"primary bitmap", nil, false, 64},
+		{"secondary bitmap", nil, true, 128},
+		{"selected fields", []int{0, 2, 11, 100}, true, 128},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := macTestMessage(t)
+			if test.secondary {
+				msg.SetString(100, "654321")
+			}
+			if nr := MacFieldNumber(msg); nr != test.macFieldNr {
+				t.Fatalf("MAC field is %d, want %d", nr, test.macFieldNr)
+			}
+			m := &Mac{Provider: macTestProvider(t), Fields: test.fields}
+			data, err := m.Generate(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if packed, err := msg.Pack(); err != nil || string(packed) != string(data) {
+				t.Fatalf("message packs as %X %v, want the generated %X", packed, err, data)
+			}
+			other := 192 - test.macFieldNr
+			if msg.IsFieldSet(other) {
+				t.Errorf("field %d is set as well as the MAC field %d", other, test.macFieldNr)
+			}
+
+			unpacked := &BitmapMessage{BitmapMessageTemplate: msg.BitmapMessageTemplate}
+			unpacked.Init()
+			if err = BitmapUnpack(data, msg.BitmapMessageTemplate, unpacked); err != nil {
+				t.Fatal(err)
+			}
+			if err = m.Verify(unpacked, data); err != nil {
+				t.Errorf("verifying: %v", err)
+			}
+
+			//Change the trace number, which every MAC covers.
+			tampered := append([]byte(nil), data...)
+			stan := len(tampered) - 8 - 1
+			if test.secondary {
+				stan = len(tampered) - 8 - len("06654321") - 1
+			}
+			tampered[stan] ^= 0x01
+			unpacked.Reset()
+			if err = BitmapUnpack(tampered, msg.BitmapMessageTemplate, unpacked); err != nil {
+				t.Fatal(err)
+			}
+			if err = m.Verify(unpacked, tampered); err != ErrInvalidMac {
+				t.Errorf("verifying tampered data returned %v, want ErrInvalidMac", err)
+			}
+		})
+	}
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Generate(data []byte) ([]byte, error) {
+	return nil, errors.New("HSM unavailable")
+}
+
+type shortProvider struct{}
+
+func (shortProvider) Generate(data []byte) ([]byte, error) {
+	return []byte{1, 2, 3, 4}, nil
+}
+
+func TestMacGenerateFailureUnsetsField(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider mac.Provider
+		fieldNr  int //A field to set which is not in the template, so the message fails to pack
+	}{
+		{"pack error", macTestProvider(t), 99},
+		{"provider error", failingProvider{}, 0},
+		{"short MAC", shortProvider{}, 0},
+	}
+	for _, test := range tests {
+		msg := macTestMessage(t)
+		msg.SetString(64, "\x01\x02\x03\x04\x05\x06\x07\x08")
+		if test.fieldNr != 0 {
+			msg.SetString(test.fieldNr, "1")
+		}
+		if _, err := (&Mac{Provider: test.provider}).Generate(msg); err == nil {
+			t.Errorf("%s: generated a MAC", test.name)
+		}
+		if msg.IsFieldSet(64) || msg.IsFieldSet(128) {
+			t.Errorf("%s: the MAC field is still set after failing", test.name)
+		}
+	}
+}
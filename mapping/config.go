@@ -0,0 +1,162 @@
+package mapping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/doswell/go8583"
+)
+
+//Config is the JSON form of a mapping, e.g.
+//
+//	{
+//	  "msgTypes": {"0200": "0100", "0210": "0110"},
+//	  "msgTypeRules": ["version1993"],
+//	  "rules": [
+//	    {"op": "copy", "fields": [2, 3, 4, 11]},
+//	    {"op": "move", "from": "48.01", "to": "62.1"},
+//	    {"op": "split", "from": "43", "to": ["43.1", "43.2"], "widths": [23, 0]},
+//	    {"op": "join", "sources": ["43.1", "43.2"], "separator": " ", "to": "43"},
+//	    {"op": "transform", "from": "39", "to": "39", "func": "lookup", "args": ["00=000", "*=909"]},
+//	    {"op": "constant", "to": "32", "value": "123456"},
+//	    {"op": "remove", "to": "52"}
+//	  ]
+//	}
+type Config struct {
+	MsgTypes     map[string]string `json:"msgTypes,omitempty"`
+	MsgTypeRules []string          `json:"msgTypeRules,omitempty"`
+	Rules        []RuleConfig      `json:"rules"`
+}
+
+//RuleConfig is one rule of a Config. Op is copy, move, split, join, transform, constant or remove, and selects which of
+//the other members are used. A split with a separator splits at the separator rather than by widths.
+type RuleConfig struct {
+	Op        string   `json:"op"`
+	Fields    []int    `json:"fields,omitempty"`
+	From      string   `json:"from,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
+	To        pathList `json:"to,omitempty"`
+	Widths    []int    `json:"widths,omitempty"`
+	Separator string   `json:"separator,omitempty"`
+	Value     string   `json:"value,omitempty"`
+	Func      string   `json:"func,omitempty"`
+	Args      []string `json:"args,omitempty"`
+}
+
+//pathList is a single path or a list of paths, as split writes to several paths.
+type pathList []string
+
+func (p *pathList) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*p = pathList{path}
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return errors.New("Rule destination must be a path or a list of paths")
+	}
+	*p = paths
+	return nil
+}
+
+//to returns the single destination path of a rule.
+func (r *RuleConfig) to() (string, error) {
+	if len(r.To) != 1 {
+		return "", errors.New(fmt.Sprint("Rule ", r.Op, " needs one destination path"))
+	}
+	return r.To[0], nil
+}
+
+//Rule creates the rule described by the config.
+func (r *RuleConfig) Rule() (Rule, error) {
+	switch r.Op {
+	case "copy":
+		return Copy(r.Fields...), nil
+	case "split":
+		if r.Separator != "" {
+			return SplitOn(r.From, r.Separator, r.To), nil
+		}
+		if len(r.Widths) != len(r.To) {
+			return nil, errors.New("Rule split needs a width for each destination path")
+		}
+		for _, width := range r.Widths {
+			if width < 0 {
+				return nil, errors.New(fmt.Sprint("Invalid split width ", width))
+			}
+		}
+		return Split(r.From, r.To, r.Widths), nil
+	case "transform":
+		fn, err := NamedTransform(r.Func, r.Args)
+		if err != nil {
+			return nil, err
+		}
+		to, err := r.to()
+		if err != nil {
+			return nil, err
+		}
+		return Transform(r.From, to, fn), nil
+	}
+
+	to, err := r.to()
+	if err != nil {
+		return nil, err
+	}
+	switch r.Op {
+	case "move":
+		return Move(r.From, to), nil
+	case "join":
+		return Join(r.Sources, r.Separator, to), nil
+	case "constant":
+		return Constant(to, r.Value), nil
+	case "remove":
+		return Remove(to), nil
+	}
+	return nil, errors.New(fmt.Sprint("Unknown rule ", r.Op))
+}
+
+//Parse creates a mapping into the target template from its JSON config.
+func Parse(data []byte, target *go8583.BitmapMessageTemplate) (*Mapping, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	m := New(target)
+	for from, to := range config.MsgTypes {
+		fromType, err := parseMsgType(from)
+		if err != nil {
+			return nil, err
+		}
+		toType, err := parseMsgType(to)
+		if err != nil {
+			return nil, err
+		}
+		m.MsgTypes[fromType] = toType
+	}
+	for _, name := range config.MsgTypeRules {
+		rule, err := NamedMsgTypeRule(name)
+		if err != nil {
+			return nil, err
+		}
+		m.MsgTypeRules = append(m.MsgTypeRules, rule)
+	}
+	for i := range config.Rules {
+		rule, err := config.Rules[i].Rule()
+		if err != nil {
+			return nil, errors.New(fmt.Sprint("Mapping rule ", i+1, ": ", err))
+		}
+		m.Rules = append(m.Rules, rule)
+	}
+	return m, nil
+}
+
+//Load reads a mapping into the target template from a JSON file, see Config.
+func Load(path string, target *go8583.BitmapMessageTemplate) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, target)
+}
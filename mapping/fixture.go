@@ -0,0 +1,74 @@
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/doswell/go8583"
+)
+
+//Fixture is a golden case for a mapping: a source message and the message it must translate to, both in the JSON form of
+//BitmapMessage.MarshalJSON.
+type Fixture struct {
+	Name     string          `json:"name"`
+	Input    json.RawMessage `json:"input"`
+	Expected json.RawMessage `json:"expected"`
+}
+
+//LoadFixtures reads a JSON array of fixtures from a file.
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []Fixture
+	if err = json.Unmarshal(data, &fixtures); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+//Verify translates the fixture's input, a message of the source template, and compares the result with the expected message.
+func (m *Mapping) Verify(source *go8583.BitmapMessageTemplate, fixture Fixture) error {
+	input := &go8583.BitmapMessage{BitmapMessageTemplate: source}
+	input.Init()
+	if err := input.UnmarshalJSON(fixture.Input); err != nil {
+		return errors.New(fmt.Sprint("Fixture ", fixture.Name, " input: ", err))
+	}
+	output, err := m.Translate(input)
+	if err != nil {
+		return errors.New(fmt.Sprint("Fixture ", fixture.Name, ": ", err))
+	}
+	got, err := output.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	//Decode and encode the expected message so field order and hex case do not matter.
+	expected := &go8583.BitmapMessage{BitmapMessageTemplate: m.Target}
+	expected.Init()
+	if err = expected.UnmarshalJSON(fixture.Expected); err != nil {
+		return errors.New(fmt.Sprint("Fixture ", fixture.Name, " expected: ", err))
+	}
+	want, err := expected.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return errors.New(fmt.Sprint("Fixture ", fixture.Name, " got ", string(got), " want ", string(want)))
+	}
+	return nil
+}
+
+//VerifyAll checks every fixture, returning the first failure.
+func (m *Mapping) VerifyAll(source *go8583.BitmapMessageTemplate, fixtures []Fixture) error {
+	for _, fixture := range fixtures {
+		if err := m.Verify(source, fixture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
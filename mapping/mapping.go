@@ -0,0 +1,60 @@
+//Package mapping translates messages from one template to another, as a switch does between network dialects. A Mapping
+//is a list of rules which move, split, join, transform and set fields by path, such as "48.01" or "43.2", a table of
+//message types and rules deriving message types, such as the response or the 1993 version. Mappings can be loaded from a
+//JSON file and checked against golden fixtures.
+package mapping
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583"
+)
+
+//Rule writes part of the destination message, usually from the source message.
+type Rule interface {
+	Apply(src, dst *go8583.BitmapMessage) error
+}
+
+//Mapping translates messages into the Target template.
+type Mapping struct {
+	Target *go8583.BitmapMessageTemplate
+	//MsgTypes maps source message types to destination message types, e.g. 0x0200 to 0x0100.
+	MsgTypes map[int]int
+	//MsgTypeRules derive the destination message type of source types not in MsgTypes, each applied in order to the
+	//type given by the one before, e.g. Version1993 then Response. Types no rule applies to are kept.
+	MsgTypeRules []MsgTypeRule
+	//Rules are applied in order, so later rules may overwrite or remove what earlier rules wrote.
+	Rules []Rule
+}
+
+//New creates a mapping into the target template.
+func New(target *go8583.BitmapMessageTemplate, rules ...Rule) *Mapping {
+	return &Mapping{Target: target, MsgTypes: make(map[int]int), Rules: rules}
+}
+
+//Translate returns a new message of the target template built from src by the rules. src is not changed.
+func (m *Mapping) Translate(src *go8583.BitmapMessage) (*go8583.BitmapMessage, error) {
+	dst := &go8583.BitmapMessage{BitmapMessageTemplate: m.Target}
+	dst.Init()
+	dst.SetMsgType(m.MsgType(src.GetMsgType()))
+	for i, rule := range m.Rules {
+		if err := rule.Apply(src, dst); err != nil {
+			return nil, errors.New(fmt.Sprint("Mapping rule ", i+1, ": ", err))
+		}
+	}
+	return dst, nil
+}
+
+//MsgType returns the destination message type of a source message type.
+func (m *Mapping) MsgType(msgType int) int {
+	if mapped, ok := m.MsgTypes[msgType]; ok {
+		return mapped
+	}
+	for _, rule := range m.MsgTypeRules {
+		if derived, ok := rule(msgType); ok {
+			msgType = derived
+		}
+	}
+	return msgType
+}
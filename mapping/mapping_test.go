@@ -0,0 +1,125 @@
+package mapping
+
+import (
+	"testing"
+
+	"github.com/doswell/go8583"
+)
+
+func sourceTemplate() *go8583.BitmapMessageTemplate {
+	return &go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewLlVarField(2, "pan", 19, go8583.Numeric),
+		go8583.NewFixedField(3, "processingCode", 6, go8583.Numeric),
+		go8583.NewFixedField(4, "amountTransaction", 12, go8583.Numeric),
+		go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
+		go8583.NewFixedField(39, "responseCode", 2, go8583.AlphaNumeric),
+		go8583.NewFixedField(43, "cardAcceptorNameLocation", 40, go8583.AlphaNumericSpecial),
+		go8583.NewTlvField(48, "additionalData", 999, go8583.NewVariableFieldPackerUnpacker(3), go8583.AsciiTlv2x2, nil),
+		go8583.NewFixedField(49, "currencyCode", 3, go8583.Numeric),
+	)}
+}
+
+func targetTemplate() *go8583.BitmapMessageTemplate {
+	return &go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewLlVarField(2, "pan", 19, go8583.Numeric),
+		go8583.NewFixedField(3, "processingCode", 6, go8583.Numeric),
+		go8583.NewFixedField(4, "amountTransaction", 12, go8583.Numeric),
+		go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
+		go8583.NewLlVarField(32, "acquiringInstId", 11, go8583.Numeric),
+		go8583.NewFixedField(39, "actionCode", 3, go8583.Numeric),
+		go8583.NewPositionalField(43, "cardAcceptor", 40, go8583.NewVariableFieldPackerUnpacker(2), []go8583.Field{
+			go8583.NewFixedField(1, "name", 25, go8583.AlphaNumericSpecial),
+			go8583.NewFixedField(2, "city", 13, go8583.AlphaNumericSpecial),
+			go8583.NewFixedField(3, "country", 2, go8583.Alpha),
+		}),
+		go8583.NewFixedField(49, "currencyCode", 3, go8583.Numeric),
+		go8583.NewPositionalField(62, "privateData", 4, go8583.NewVariableFieldPackerUnpacker(3), []go8583.Field{
+			go8583.NewFixedField(1, "reference", 4, go8583.AlphaNumeric),
+		}),
+	)}
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	m, err := Load("testdata/mapping.json", targetTemplate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFixtures("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := sourceTemplate()
+	for _, fixture := range fixtures {
+		if err := m.Verify(source, fixture); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMsgTypeRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    MsgTypeRule
+		msgType int
+		want    int
+		applies bool
+	}{
+		{"response to request", Response, 0x0100, 0x0110, true},
+		{"response to advice repeat", Response, 0x0421, 0x0430, true},
+		{"response to network management", Response, 0x1804, 0x1814, true},
+		{"response to response", Response, 0x0210, 0x0210, false},
+		{"1993 financial", Version1993, 0x0200, 0x1200, true},
+		{"1993 reversal repeat", Version1993, 0x0421, 0x1421, true},
+		{"1993 network management", Version1993, 0x0800, 0x1804, true},
+		{"1993 network management response", Version1993, 0x0810, 0x1814, true},
+		{"1993 already", Version1993, 0x1200, 0x1200, false},
+		{"1987 financial response", Version1987, 0x1210, 0x0210, true},
+		{"1987 network management", Version1987, 0x1804, 0x0800, true},
+		{"1987 network management response", Version1987, 0x1814, 0x0810, true},
+		{"1987 already", Version1987, 0x0200, 0x0200, false},
+	}
+	for _, test := range tests {
+		got, applies := test.rule(test.msgType)
+		if got != test.want || applies != test.applies {
+			t.Errorf("%s: %04X gave %04X %v, want %04X %v", test.name, test.msgType, got, applies, test.want, test.applies)
+		}
+	}
+
+	m := New(targetTemplate())
+	m.MsgTypeRules = []MsgTypeRule{Version1993, Response}
+	m.MsgTypes[0x0420] = 0x1420
+	for msgType, want := range map[int]int{0x0200: 0x1210, 0x0800: 0x1814, 0x0420: 0x1420, 0x1210: 0x1210} {
+		if got := m.MsgType(msgType); got != want {
+			t.Errorf("mapped %04X to %04X, want %04X", msgType, got, want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"msgTypes": {"200": "1200"}, "rules": []}`,
+		`{"msgTypes": {"0200": "12G0"}, "rules": []}`,
+		`{"msgTypeRules": ["version2003"], "rules": []}`,
+		`{"rules": [{"op": "split", "from": "43", "to": ["43.1", "43.2"], "widths": [-1, 0]}]}`,
+		`{"rules": [{"op": "split", "from": "43", "to": ["43.1", "43.2"], "widths": [25]}]}`,
+		`{"rules": [{"op": "transform", "from": "32", "to": "32", "func": "padLeft", "args": ["-2", "0"]}]}`,
+		`{"rules": [{"op": "transform", "from": "32", "to": "32", "func": "substring", "args": ["-1"]}]}`,
+		`{"rules": [{"op": "transform", "from": "32", "to": "32", "func": "unknown"}]}`,
+		`{"rules": [{"op": "move", "from": "48.01", "to": ["62.1", "62.2"]}]}`,
+		`{"rules": [{"op": "unknown"}]}`,
+	} {
+		if _, err := Parse([]byte(config), targetTemplate()); err == nil {
+			t.Errorf("parsed %s without error", config)
+		}
+	}
+}
+
+func TestSplitNegativeWidth(t *testing.T) {
+	src := &go8583.BitmapMessage{BitmapMessageTemplate: sourceTemplate()}
+	src.Init()
+	src.SetString(43, "ACME")
+	m := New(targetTemplate(), Split("43", []string{"43.1", "43.2"}, []int{-1, 0}))
+	if _, err := m.Translate(src); err == nil {
+		t.Error("split with a negative width without error")
+	}
+}
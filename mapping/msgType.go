@@ -0,0 +1,73 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//MsgTypeRule derives a destination message type from a source message type, returning false if it does not apply.
+type MsgTypeRule func(msgType int) (int, bool)
+
+//msgTypeRules are the message type rules available to mapping files by name.
+var msgTypeRules = map[string]MsgTypeRule{
+	"response":    Response,
+	"version1987": Version1987,
+	"version1993": Version1993,
+}
+
+//Response derives the response to a request or advice, keeping the origin but not the repeat, e.g. 0200 gives 0210,
+//0421 gives 0430 and 1804 gives 1814. It does not apply to responses, whose function digit is odd.
+func Response(msgType int) (int, bool) {
+	if msgType&0x0010 != 0 {
+		return msgType, false
+	}
+	return msgType&^0x0001 + 0x0010, true
+}
+
+//Version1993 derives the ISO 8583:1993 message type of a 1987 message type, e.g. 0200 gives 1200. Network management
+//requests and responses become 1804 and 1814, as 1993 has no 0800 function.
+func Version1993(msgType int) (int, bool) {
+	if msgType&0xF000 != 0x0000 {
+		return msgType, false
+	}
+	switch msgType & 0x0FF0 {
+	case 0x0800:
+		return 0x1804, true
+	case 0x0810:
+		return 0x1814, true
+	}
+	return msgType | 0x1000, true
+}
+
+//Version1987 derives the ISO 8583:1987 message type of a 1993 message type, e.g. 1200 gives 0200 and 1804 gives 0800.
+func Version1987(msgType int) (int, bool) {
+	if msgType&0xF000 != 0x1000 {
+		return msgType, false
+	}
+	switch msgType & 0x0FF0 {
+	case 0x0800:
+		return 0x0800, true
+	case 0x0810:
+		return 0x0810, true
+	}
+	return msgType &^ 0xF000, true
+}
+
+//NamedMsgTypeRule returns the message type rule of the name: response, version1987 or version1993.
+func NamedMsgTypeRule(name string) (MsgTypeRule, error) {
+	rule, ok := msgTypeRules[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("Unknown message type rule ", name))
+	}
+	return rule, nil
+}
+
+//parseMsgType reads a message type of four hex digits, e.g. 0200.
+func parseMsgType(s string) (int, error) {
+	msgType, err := strconv.ParseUint(s, 16, 16)
+	if err != nil || len(s) != 4 {
+		return 0, errors.New(fmt.Sprint("Invalid message type ", s))
+	}
+	return int(msgType), nil
+}
@@ -0,0 +1,170 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/doswell/go8583"
+)
+
+type copyRule struct {
+	fieldNrs []int
+}
+
+//Copy copies fields to the same field numbers, with any subfields. With no field numbers every field the target template
+//defines is copied.
+func Copy(fieldNrs ...int) Rule {
+	return &copyRule{fieldNrs}
+}
+
+func (r *copyRule) Apply(src, dst *go8583.BitmapMessage) error {
+	if len(r.fieldNrs) > 0 {
+		dst.CopyFields(src, r.fieldNrs...)
+		return nil
+	}
+	for fieldNr := range src.FieldValues {
+		if _, err := dst.GetFieldDef(fieldNr); err == nil {
+			dst.CopyFields(src, fieldNr)
+		}
+	}
+	return nil
+}
+
+type moveRule struct {
+	from, to string
+}
+
+//Move copies the field or subfield at one path of the source to another path of the destination, such as "48.01" to "62.1".
+func Move(from, to string) Rule {
+	return &moveRule{from, to}
+}
+
+func (r *moveRule) Apply(src, dst *go8583.BitmapMessage) error {
+	value, isSet := src.GetPathValue(r.from)
+	if !isSet {
+		return nil
+	}
+	return dst.SetPathValue(r.to, value.Clone())
+}
+
+type splitRule struct {
+	from      string
+	to        []string
+	widths    []int
+	separator string
+}
+
+//Split divides the value at one path into the destination paths by width. A width of 0 takes the rest of the value.
+func Split(from string, to []string, widths []int) Rule {
+	return &splitRule{from: from, to: to, widths: widths}
+}
+
+//SplitOn divides the value at one path into the destination paths at each separator. The last path takes the rest.
+func SplitOn(from string, separator string, to []string) Rule {
+	return &splitRule{from: from, to: to, separator: separator}
+}
+
+func (r *splitRule) Apply(src, dst *go8583.BitmapMessage) error {
+	value, isSet := src.Get(r.from)
+	if !isSet {
+		return nil
+	}
+	var parts []string
+	if r.separator != "" {
+		parts = strings.SplitN(value, r.separator, len(r.to))
+	} else {
+		if len(r.widths) != len(r.to) {
+			return errors.New(fmt.Sprint("Split of ", r.from, " needs a width for each destination"))
+		}
+		for _, width := range r.widths {
+			if width < 0 {
+				return errors.New(fmt.Sprint("Invalid split width ", width))
+			}
+			if width == 0 || width > len(value) {
+				width = len(value)
+			}
+			parts = append(parts, value[:width])
+			value = value[width:]
+		}
+	}
+	for i, part := range parts {
+		if err := dst.Set(r.to[i], part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type joinRule struct {
+	from      []string
+	separator string
+	to        string
+}
+
+//Join concatenates the values at the source paths, separated by separator, into the destination path. Paths which are
+//not set are skipped.
+func Join(from []string, separator string, to string) Rule {
+	return &joinRule{from, separator, to}
+}
+
+func (r *joinRule) Apply(src, dst *go8583.BitmapMessage) error {
+	var parts []string
+	for _, path := range r.from {
+		if value, isSet := src.Get(path); isSet {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return dst.Set(r.to, strings.Join(parts, r.separator))
+}
+
+type transformRule struct {
+	from, to string
+	fn       TransformFunc
+}
+
+//Transform writes the value at one path of the source, changed by fn, to a path of the destination.
+func Transform(from, to string, fn TransformFunc) Rule {
+	return &transformRule{from, to, fn}
+}
+
+func (r *transformRule) Apply(src, dst *go8583.BitmapMessage) error {
+	value, isSet := src.Get(r.from)
+	if !isSet {
+		return nil
+	}
+	value, err := r.fn(value)
+	if err != nil {
+		return errors.New(fmt.Sprint("Transform of ", r.from, ": ", err))
+	}
+	return dst.Set(r.to, value)
+}
+
+type constantRule struct {
+	to, value string
+}
+
+//Constant sets a path of the destination to a fixed value, such as an acquirer ID in DE32.
+func Constant(to, value string) Rule {
+	return &constantRule{to, value}
+}
+
+func (r *constantRule) Apply(src, dst *go8583.BitmapMessage) error {
+	return dst.Set(r.to, r.value)
+}
+
+type removeRule struct {
+	path string
+}
+
+//Remove unsets a path of the destination, such as a field copied by Copy which the destination network does not accept.
+func Remove(path string) Rule {
+	return &removeRule{path}
+}
+
+func (r *removeRule) Apply(src, dst *go8583.BitmapMessage) error {
+	return dst.Unset(r.path)
+}
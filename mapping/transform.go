@@ -0,0 +1,133 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/doswell/go8583/util"
+)
+
+//TransformFunc changes a value as it is mapped.
+type TransformFunc func(value string) (string, error)
+
+//TransformFactory creates a TransformFunc from the arguments given in a mapping file.
+type TransformFactory func(args []string) (TransformFunc, error)
+
+var transformLock sync.RWMutex
+
+//transforms are the named transforms available to mapping files.
+var transforms = map[string]TransformFactory{
+	"upper": noArgs(func(value string) (string, error) {
+		return strings.ToUpper(value), nil
+	}),
+	"lower": noArgs(func(value string) (string, error) {
+		return strings.ToLower(value), nil
+	}),
+	"trim": noArgs(func(value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	}),
+	"trimLeadingZeros": noArgs(func(value string) (string, error) {
+		trimmed := strings.TrimLeft(value, "0")
+		if trimmed == "" && value != "" {
+			trimmed = "0"
+		}
+		return trimmed, nil
+	}),
+	"padLeft":   padTransform(util.LeftPad2Len),
+	"padRight":  padTransform(util.RightPad2Len),
+	"substring": substringTransform,
+	"lookup":    lookupTransform,
+}
+
+//RegisterTransform makes a transform available to mapping files by name, replacing any transform of the same name.
+func RegisterTransform(name string, factory TransformFactory) {
+	transformLock.Lock()
+	defer transformLock.Unlock()
+	transforms[name] = factory
+}
+
+//NamedTransform returns the registered transform of the name, created with the arguments.
+func NamedTransform(name string, args []string) (TransformFunc, error) {
+	transformLock.RLock()
+	factory, ok := transforms[name]
+	transformLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprint("Unknown transform ", name))
+	}
+	return factory(args)
+}
+
+func noArgs(fn TransformFunc) TransformFactory {
+	return func(args []string) (TransformFunc, error) {
+		if len(args) != 0 {
+			return nil, errors.New("Transform takes no arguments")
+		}
+		return fn, nil
+	}
+}
+
+//padTransform pads to a length with a character, args length and pad character.
+func padTransform(pad func(s string, padStr string, overallLen int) string) TransformFactory {
+	return func(args []string) (TransformFunc, error) {
+		if len(args) != 2 || len(args[1]) != 1 {
+			return nil, errors.New("Pad takes a length and a pad character")
+		}
+		length, err := strconv.Atoi(args[0])
+		if err != nil || length < 0 {
+			return nil, errors.New(fmt.Sprint("Invalid pad length ", args[0]))
+		}
+		return func(value string) (string, error) {
+			return pad(value, args[1], length), nil
+		}, nil
+	}
+}
+
+//substringTransform takes part of the value, args start and optionally end.
+func substringTransform(args []string) (TransformFunc, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, errors.New("Substring takes a start and optionally an end")
+	}
+	bounds := make([]int, len(args))
+	for i, arg := range args {
+		bound, err := strconv.Atoi(arg)
+		if err != nil || bound < 0 {
+			return nil, errors.New(fmt.Sprint("Invalid substring bound ", arg))
+		}
+		bounds[i] = bound
+	}
+	return func(value string) (string, error) {
+		start, end := bounds[0], len(value)
+		if len(bounds) == 2 && bounds[1] < end {
+			end = bounds[1]
+		}
+		if start > end {
+			return "", nil
+		}
+		return value[start:end], nil
+	}, nil
+}
+
+//lookupTransform replaces values from a table, args "from=to". A "*=to" argument is the default, otherwise values not
+//in the table are an error.
+func lookupTransform(args []string) (TransformFunc, error) {
+	table := make(map[string]string, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New(fmt.Sprint("Invalid lookup entry ", arg))
+		}
+		table[parts[0]] = parts[1]
+	}
+	return func(value string) (string, error) {
+		if mapped, ok := table[value]; ok {
+			return mapped, nil
+		}
+		if mapped, ok := table["*"]; ok {
+			return mapped, nil
+		}
+		return "", errors.New(fmt.Sprint("No lookup entry for ", value))
+	}, nil
+}
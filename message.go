@@ -2,12 +2,13 @@ package go8583
 
 import (
 	"bytes"
-	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/bits"
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/doswell/go8583/util"
 )
@@ -29,10 +30,23 @@ type Message interface {
 	GetMsgTypeString() string
 }
 
+//BitmapMessageTemplate defines the fields of a message. Fields may be added with AddField until the template is frozen,
+//after which it is immutable and safe to share between goroutines. Fields must not be modified directly once the
+//template is in use.
 type BitmapMessageTemplate struct {
 	Header []Field
 	Fields map[int]Field
-	lock   sync.Mutex
+	Rules  map[int][]FieldRule //Presence rules by message type
+	//Validators are further checks run by Validate for every message type.
+	Validators []MessageValidator
+	//AutoFill assigns fields which are not set when ApplyAutoFill is called, such as the STAN.
+	AutoFill map[int]AutoFiller
+	//Now returns the time used for auto filled and partial date fields, time.Now if nil.
+	Now func() time.Time
+	//ForceSecondaryBitmap always includes the secondary bitmap, for networks which require it.
+	ForceSecondaryBitmap bool
+	lock                 sync.RWMutex
+	frozen               int32
 }
 
 type BitmapMessage struct {
@@ -46,6 +60,18 @@ func (m *BitmapMessage) Init() {
 
 }
 
+//Reset clears the message type and all field values so the message can be reused with the same template.
+func (m *BitmapMessage) Reset() {
+	m.MessageType = 0
+	if m.FieldValues == nil {
+		m.Init()
+		return
+	}
+	for fieldNr := range m.FieldValues {
+		delete(m.FieldValues, fieldNr)
+	}
+}
+
 type Unpacker interface {
 	Unpack(offset int, data []byte) (newOffset int, fieldData []byte, err error)
 }
@@ -58,6 +84,11 @@ type PackerUnpacker interface {
 	Packer
 }
 
+//Appender is implemented by packers which can append packed field data to a buffer without allocating.
+type Appender interface {
+	Append(dst []byte, fieldData string) ([]byte, error)
+}
+
 type FieldUnpacker interface {
 	UnpackField(offset int, data []byte) (newOffset int, fieldValue FieldValue, err error)
 }
@@ -97,6 +128,12 @@ type Field interface {
 	FieldPackerUnpacker
 }
 
+//compositeField is implemented by fields made up of subfields, allowing one composite to be nested within another.
+type compositeField interface {
+	unpackSubFields(data []byte) (value FieldValue, err error)
+	packSubFields(value FieldValue) (data []byte, err error)
+}
+
 type SubField interface {
 	GetSubField(field int) (value string, err error)
 }
@@ -109,12 +146,16 @@ type BitmapMessageField struct {
 	Size        int                 //Maximum size of field
 	PackerUnpacker
 	FieldPackerUnpacker
+	Padding *Padding //Padding of fixed fields, if not the default for the type
 }
 
 type FieldValue struct {
 	//	Field
 	Value       string
 	FieldValues map[int]FieldValue
+	//Tlvs holds EMV data objects, or the tags of private TLV subfields, in the order they were unpacked, including
+	//repeated tags which FieldValues cannot address, so the field packs back to the same bytes.
+	Tlvs []BerTlv
 	fmt.Stringer
 }
 
@@ -122,6 +163,34 @@ func (f *FieldValue) String() string {
 	return f.Value
 }
 
+//Clone returns a deep copy of the value and its subfields.
+func (f FieldValue) Clone() FieldValue {
+	return FieldValue{Value: f.Value, FieldValues: cloneFieldValues(f.FieldValues), Tlvs: cloneTlvs(f.Tlvs)}
+}
+
+//cloneTlvs copies data objects with their values and nested templates.
+func cloneTlvs(tlvs []BerTlv) []BerTlv {
+	if tlvs == nil {
+		return nil
+	}
+	clone := make([]BerTlv, len(tlvs))
+	for i, tlv := range tlvs {
+		clone[i] = BerTlv{Tag: tlv.Tag, Value: append([]byte(nil), tlv.Value...), Tlvs: cloneTlvs(tlv.Tlvs)}
+	}
+	return clone
+}
+
+func cloneFieldValues(values map[int]FieldValue) map[int]FieldValue {
+	if values == nil {
+		return nil
+	}
+	clone := make(map[int]FieldValue, len(values))
+	for fieldNr, v := range values {
+		clone[fieldNr] = v.Clone()
+	}
+	return clone
+}
+
 func (f *BitmapMessageField) GetFieldNumber() int {
 	return f.FieldNumber
 }
@@ -144,15 +213,56 @@ func (f *BitmapMessageField) ValidateValue(value string) (valid bool, err error)
 
 func (f *BitmapMessageField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
 	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
 	value = FieldValue{Value: string(fieldData)}
+	if f.GetLength() == Fixed {
+		value.Value = f.GetPadding().Unpad(value.Value)
+	}
 	return newOffset, value, nil
 	//return offset, "", errors.New("Undefined unpacking")
 }
+
+//unpackValue is UnpackField taking the value as a substring of s, the message data as a string, so that no string is
+//allocated per field. Packers other than the fixed and variable ones may not read the field data in place and are
+//left to UnpackField.
+func (f *BitmapMessageField) unpackValue(offset int, data []byte, s string) (newOffset int, value FieldValue, err error) {
+	switch f.PackerUnpacker.(type) {
+	case *fixedField, *variableField:
+	default:
+		return f.UnpackField(offset, data)
+	}
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value = FieldValue{Value: s[newOffset-len(fieldData) : newOffset]}
+	if f.GetLength() == Fixed {
+		value.Value = f.GetPadding().Unpad(value.Value)
+	}
+	return newOffset, value, nil
+}
+
+//plainField returns the field definition of fields whose values are unpacked as is, or nil for composite and other fields.
+func plainField(field Field) *BitmapMessageField {
+	switch f := field.(type) {
+	case *BitmapMessageField:
+		return f
+	case *describedField:
+		return f.BitmapMessageField
+	}
+	return nil
+}
 func (f *BitmapMessageField) PackField(value FieldValue) (data []byte, err error) {
 
 	var fieldData []byte
 	if f.GetLength() == Fixed {
-		fieldData, err = f.Pack([]byte(util.LeftPad2Len(value.Value, " ", f.GetSize())))
+		var padded string
+		if padded, err = f.GetPadding().Pad(value.Value, f.GetSize()); err != nil {
+			return nil, err
+		}
+		fieldData, err = f.Pack([]byte(padded))
 	} else {
 		fieldData, err = f.Pack([]byte(value.Value))
 	}
@@ -160,6 +270,10 @@ func (f *BitmapMessageField) PackField(value FieldValue) (data []byte, err error
 }
 
 func (f *BitmapMessageTemplate) GetFieldDef(fieldNumber int) (field Field, err error) {
+	if !f.IsFrozen() {
+		f.lock.RLock()
+		defer f.lock.RUnlock()
+	}
 	field, exists := f.Fields[fieldNumber]
 	if !exists {
 		return nil, errors.New("Field not found")
@@ -170,15 +284,22 @@ func (f *BitmapMessageTemplate) GetFieldDef(fieldNumber int) (field Field, err e
 func (m *BitmapMessage) SetString(fieldNr int, value string) {
 	m.SetField(fieldNr, FieldValue{Value: value})
 }
+
+//SetField sets a field. Field 1, the secondary bitmap, is computed when packing, so setting it is a no-op and the
+//value is ignored. Use Set, which returns an error for field 1, to catch this.
 func (m *BitmapMessage) SetField(fieldNr int, value FieldValue) {
-	if fieldNr > 64 && !m.IsFieldSet(1) {
-		m.SetField(1, *new(FieldValue))
+	if fieldNr == 1 {
+		return
 	}
-
 	m.FieldValues[fieldNr] = value
 	//TODO: Validate field?
 }
 
+//UnsetField removes a field. The bitmaps reflect the fields that remain when the message is packed.
+func (m *BitmapMessage) UnsetField(fieldNr int) {
+	delete(m.FieldValues, fieldNr)
+}
+
 func (m *BitmapMessage) SetSubField(fieldNr int, subFieldNr int, value string) {
 
 	if _, ok := m.FieldValues[fieldNr]; !ok {
@@ -215,12 +336,46 @@ func (m *BitmapMessage) GetString(fieldNr int) (value string, isSet bool) {
 	return m.GetField(fieldNr)
 }
 
+//CopyField copies a field, including any subfields, from msg.
 func (m *BitmapMessage) CopyField(fieldNr int, msg Message) {
-	v, set := msg.GetField(fieldNr)
-	if set {
-		m.SetString(fieldNr, v)
+	m.CopyFields(msg, fieldNr)
+}
+
+//fieldValueGetter is implemented by messages which can return a field with its subfields.
+type fieldValueGetter interface {
+	GetFieldValue(fieldNr int) (FieldValue, bool)
+}
+
+//CopyFields copies the fields, including any subfields, from src. Fields not set in src are left unchanged.
+func (m *BitmapMessage) CopyFields(src Message, fieldNrs ...int) {
+	getter, hasFieldValues := src.(fieldValueGetter)
+	for _, fieldNr := range fieldNrs {
+		if hasFieldValues {
+			if v, set := getter.GetFieldValue(fieldNr); set {
+				m.SetField(fieldNr, v.Clone())
+			}
+			continue
+		}
+		if v, set := src.GetField(fieldNr); set {
+			m.SetString(fieldNr, v)
+		}
 	}
+}
 
+//GetFieldValue returns the field with its subfields. The subfields are shared with the message, use Clone to modify them separately.
+func (m *BitmapMessage) GetFieldValue(fieldNr int) (value FieldValue, isSet bool) {
+	value, isSet = m.FieldValues[fieldNr]
+	return value, isSet
+}
+
+//Clone returns a deep copy of the message sharing the same template, for building responses and reversals from stored messages.
+func (m *BitmapMessage) Clone() *BitmapMessage {
+	clone := &BitmapMessage{BitmapMessageTemplate: m.BitmapMessageTemplate, MessageType: m.MessageType}
+	clone.FieldValues = cloneFieldValues(m.FieldValues)
+	if clone.FieldValues == nil {
+		clone.Init()
+	}
+	return clone
 }
 
 func (m *BitmapMessage) GetMsgType() int {
@@ -232,61 +387,95 @@ func (m *BitmapMessage) GetMsgTypeString() string {
 }
 
 func (m *BitmapMessage) Pack() ([]byte, error) {
-	buf := new(bytes.Buffer)
+	return m.AppendPack(nil)
+}
 
-	buf.WriteString(m.GetMsgTypeString())
+//AppendPack appends the packed message to dst and returns the extended buffer. Passing a reused buffer, e.g. buf[:0],
+//avoids allocating for the message data.
+func (m *BitmapMessage) AppendPack(dst []byte) ([]byte, error) {
+	dst = appendMsgType(dst, m.GetMsgType())
 
 	//Generate the bitmap based on set fields.
-	bytes, err := PackBitmapFields(m.FieldValues, m.BitmapMessageTemplate)
-	if err != nil {
-		return nil, err
+	return appendBitmapFields(dst, m.FieldValues, m.BitmapMessageTemplate)
+}
+
+const hexDigits = "0123456789abcdef"
+
+func appendMsgType(dst []byte, msgType int) []byte {
+	for shift := uint(12); ; shift -= 4 {
+		dst = append(dst, hexDigits[(msgType>>shift)&0xF])
+		if shift == 0 {
+			return dst
+		}
 	}
-	buf.Write(bytes)
-	return buf.Bytes(), nil
 }
 
 func PackBitmapFields(fieldValues map[int]FieldValue, tmpl *BitmapMessageTemplate) (data []byte, err error) {
-	buf := new(bytes.Buffer)
-	bufFields := new(bytes.Buffer)
-
-	var setFields []int
-	for m := range fieldValues {
-		setFields = append(setFields, m)
+	return appendBitmapFields(nil, fieldValues, tmpl)
+}
+
+//appendBitmapFields appends the bitmaps followed by each field in bitmap order. The secondary bitmap is included when
+//fields beyond 64 are set.
+func appendBitmapFields(dst []byte, fieldValues map[int]FieldValue, tmpl *BitmapMessageTemplate) (data []byte, err error) {
+	//Presence of each field, bit 0 of the first word being field 1.
+	var present [2]uint64
+	lastFieldNr := 0
+	for fieldNr := range fieldValues {
+		if fieldNr < 2 || fieldNr > 128 {
+			return nil, errors.New(fmt.Sprint("Field ", fieldNr, " set outside of bitmap"))
+		}
+		present[(fieldNr-1)/64] |= 1 << uint((fieldNr-1)%64)
+		if fieldNr > lastFieldNr {
+			lastFieldNr = fieldNr
+		}
 	}
-	sort.Ints(setFields)
-	var bitmapSize int = 1
-	if _, ok := fieldValues[1]; ok {
-		bitmapSize = 2
+	bitmapSize := 8
+	if lastFieldNr > 64 || tmpl.ForceSecondaryBitmap {
+		bitmapSize = 16
+		present[0] |= 1
 	}
-	bitMap := make([]uint64, bitmapSize)
 
-	for _, i := range setFields {
-		ui := uint64(i)
-		//bitMap[i/64] |= 1 << (64 - (ui - (ui/64)*64))
-		//bitMap[i/64] |= 1 << (64 - ui%64)
-		bitMap[i/64] |= 1 << (ui%64 - 1) //We set each bit accordingly, then reverse the whole map when packing
-
-		if i > 1 {
-			f, ok := tmpl.Fields[i]
-			if !ok {
-				fmt.Println("Template missing: ", i)
+	bitmapStart := len(dst)
+	dst = append(dst, make([]byte, bitmapSize)...)
+	for word := 0; word < bitmapSize/8; word++ {
+		for set := present[word]; set != 0; set &= set - 1 {
+			fieldNr := word*64 + bits.TrailingZeros64(set) + 1
+			dst[bitmapStart+(fieldNr-1)/8] |= 0x80 >> uint((fieldNr-1)%8)
+			if fieldNr == 1 {
+				continue
 			}
-			fieldBytes, err := f.PackField(fieldValues[i])
+			f, err := tmpl.GetFieldDef(fieldNr)
 			if err != nil {
-				fmt.Println("error packing field ", i, " ", err)
-				continue
+				return nil, errors.New(fmt.Sprint("Template missing field ", fieldNr))
+			}
+			if dst, err = appendField(dst, f, fieldValues[fieldNr]); err != nil {
+				return nil, errors.New(fmt.Sprint("Error packing field ", fieldNr, ": ", err))
 			}
-			binary.Write(bufFields, binary.LittleEndian, fieldBytes)
 		}
-
 	}
+	return dst, nil
+}
 
-	for _, b := range bitMap {
-		binary.Write(buf, binary.BigEndian, util.ReverseUint64Bits(b))
+//isBitmapSet returns true if the bit for fieldNr is set.
+func isBitmapSet(bitmap []byte, fieldNr int) bool {
+	return bitmap[(fieldNr-1)/8]&(0x80>>uint((fieldNr-1)%8)) != 0
+}
+
+//appendField packs plain fields straight into dst. Composite fields are packed through PackField.
+func appendField(dst []byte, field Field, value FieldValue) ([]byte, error) {
+	if f, ok := field.(*BitmapMessageField); ok {
+		if _, isFixed := f.PackerUnpacker.(*fixedField); isFixed {
+			return f.GetPadding().appendPadded(dst, value.Value, f.GetSize())
+		}
+		if appender, ok := f.PackerUnpacker.(Appender); ok {
+			return appender.Append(dst, value.Value)
+		}
+	}
+	fieldData, err := field.PackField(value)
+	if err != nil {
+		return nil, err
 	}
-	//Write fields
-	bufFields.WriteTo(buf)
-	return buf.Bytes(), nil
+	return append(dst, fieldData...), nil
 }
 
 func (m *BitmapMessage) SetMsgType(msgType int) {
@@ -305,10 +494,12 @@ func CreateFields(fields ...Field) map[int]Field {
 	return fieldMap
 }
 
+//BitmapUnpack unpacks the message data into msg using the template. The values of plain fields share one copy of the
+//data, so holding on to any one value keeps the whole message in memory.
 func BitmapUnpack(data []byte, tmpl MessageTemplate, msg Message) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			err = r.(error)
+			err = errors.New(fmt.Sprint(r))
 		}
 	}()
 	if msg == nil {
@@ -328,33 +519,44 @@ func BitmapUnpack(data []byte, tmpl MessageTemplate, msg Message) (err error) {
 	i := 4
 	bitmap := data[i : i+8]
 	i = i + 8
-	if bitmap[0]&0x80 > 0 { //Extended bitmap.
-		bitmap = append(bitmap, data[i:i+8]...)
+	if isBitmapSet(bitmap, 1) { //Extended bitmap.
+		if len(data) < i+8 {
+			return errors.New("Invalid message size. Missing secondary bitmap")
+		}
+		bitmap = data[4 : i+8]
 		i = i + 8
 	}
 
-	boolBitmap := util.GetBitmap(bitmap)
-
-	for fieldNr, b := range boolBitmap {
-		fieldNr = fieldNr + 1
-		if fieldNr == 1 {
+	//Plain field values are substrings of the message data, converted to a string once.
+	var s string
+	for fieldNr := 2; fieldNr <= len(bitmap)*8; fieldNr++ {
+		if (fieldNr-1)%8 == 0 && bitmap[(fieldNr-1)/8] == 0 {
+			fieldNr += 7 //No fields set in this byte of the bitmap.
 			continue
 		}
-		if b {
-			field, err := tmpl.GetFieldDef(fieldNr)
-			if err != nil {
-				fmt.Println("Error unpacking field ", fieldNr, " : ", err)
-				return err
+		if !isBitmapSet(bitmap, fieldNr) {
+			continue
+		}
+		field, err := tmpl.GetFieldDef(fieldNr)
+		if err != nil {
+			fmt.Println("Error unpacking field ", fieldNr, " : ", err)
+			return err
+		}
+		var fieldValue FieldValue
+		if plain := plainField(field); plain != nil {
+			if s == "" {
+				s = string(data)
 			}
-			var fieldValue FieldValue
+			i, fieldValue, err = plain.unpackValue(i, data, s)
+		} else {
 			i, fieldValue, err = field.UnpackField(i, data)
+		}
 
-			if err != nil {
-				fmt.Println("Error unpacking field ", fieldNr, " : ", err)
-				return err
-			}
-			msg.SetField(fieldNr, fieldValue)
+		if err != nil {
+			fmt.Println("Error unpacking field ", fieldNr, " : ", err)
+			return err
 		}
+		msg.SetField(fieldNr, fieldValue)
 	}
 	return nil
 }
@@ -383,26 +585,24 @@ func formatFieldsToString(buf *bytes.Buffer, tmpl MessageTemplate, values map[in
 	sort.Ints(setFields)
 
 	for _, i := range setFields {
-		if i > 1 {
-			f, err := tmpl.GetFieldDef(i)
-			if err != nil {
-				fmt.Println("Template missing: ", i)
-			}
-			fieldValue := values[i]
-			if fieldValue.FieldValues != nil {
-				mt, ok := f.(MessageTemplate)
-				if ok {
-					formatFieldsToString(buf, mt, fieldValue.FieldValues, fmt.Sprint(strconv.Itoa(i), "."))
-				}
-			} else {
-				formatFieldToString(buf, f, fieldValue, fieldPrefix)
+		f, err := tmpl.GetFieldDef(i)
+		if err != nil {
+			fmt.Println("Template missing: ", i)
+			continue
+		}
+		fieldValue := values[i]
+		if fieldValue.FieldValues != nil {
+			mt, ok := f.(MessageTemplate)
+			if ok {
+				formatFieldsToString(buf, mt, fieldValue.FieldValues, fmt.Sprint(fieldPrefix, subFieldLabel(tmpl, f, i, false), "."))
 			}
-
+		} else {
+			formatFieldToString(buf, f, fieldValue, fieldPrefix, subFieldLabel(tmpl, f, i, true))
 		}
 	}
 }
 
-func formatFieldToString(buf *bytes.Buffer, field Field, fieldValue FieldValue, fieldNrPrefix string) {
+func formatFieldToString(buf *bytes.Buffer, field Field, fieldValue FieldValue, fieldNrPrefix string, fieldLabel string) {
 
 	buf.WriteString("\t[")
 	buf.WriteString(util.RightPad2Len(field.GetLength().String(), " ", 8))
@@ -414,11 +614,45 @@ func formatFieldToString(buf *bytes.Buffer, field Field, fieldValue FieldValue,
 
 	buf.WriteString(util.LeftPad2Len(strconv.Itoa(len(fieldValue.String())), "0", 3))
 	buf.WriteString("] ")
-	buf.WriteString(util.RightPad2Len(fmt.Sprint(fieldNrPrefix, util.LeftPad2Len(strconv.Itoa(field.GetFieldNumber()), "0", 3)), " ", 7))
+	label := fmt.Sprint(fieldNrPrefix, fieldLabel)
+	if len(label) < 7 {
+		label = util.RightPad2Len(label, " ", 7)
+	}
+	buf.WriteString(label)
 
 	buf.WriteString(" [")
-	buf.WriteString(fieldValue.String())
+	buf.WriteString(displayValue(field, fieldValue))
 	buf.WriteString("]")
+	if d, ok := field.(describer); ok {
+		if description := d.describe(fieldValue); description != "" {
+			buf.WriteString(" ")
+			buf.WriteString(description)
+		}
+	}
 	buf.WriteString("\n")
 
 }
+
+//labeler is implemented by fields which are not identified by a decimal field number, such as EMV tags.
+type labeler interface {
+	FieldLabel() string
+}
+
+//describer is implemented by fields which can annotate their value in String() output.
+type describer interface {
+	describe(value FieldValue) string
+}
+
+//subFieldLabel labels a field as its parent addresses it, otherwise by its own label or number.
+func subFieldLabel(parent MessageTemplate, field Field, fieldNr int, padded bool) string {
+	if f, ok := parent.(subFieldFormatter); ok {
+		return f.FormatSubField(fieldNr)
+	}
+	if l, ok := field.(labeler); ok {
+		return l.FieldLabel()
+	}
+	if padded {
+		return util.LeftPad2Len(strconv.Itoa(fieldNr), "0", 3)
+	}
+	return strconv.Itoa(fieldNr)
+}
@@ -0,0 +1,147 @@
+package go8583
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnpackPlainFields(t *testing.T) {
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(4, "amountTransaction", 12, Numeric),
+		NewPosEntryModeField(22, "posEntryMode"),
+		NewFixedField(43, "cardAcceptorName", 40, AlphaNumericSpecial),
+		NewLllVarField(100, "receivingInstId", 11, Numeric),
+	)}).Freeze()
+	values := map[int]string{2: "4012345678909", 4: "000000001000", 22: "051", 43: "SHOP" + strings.Repeat(" ", 36), 100: "654321"}
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	for fieldNr, value := range values {
+		msg.SetString(fieldNr, value)
+	}
+	data, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	unpacked.Init()
+	if err = BitmapUnpack(data, tmpl, unpacked); err != nil {
+		t.Fatal(err)
+	}
+	if len(unpacked.FieldValues) != len(values) {
+		t.Errorf("unpacked %d fields, want %d", len(unpacked.FieldValues), len(values))
+	}
+	for fieldNr, want := range values {
+		if got, _ := unpacked.GetString(fieldNr); got != want {
+			t.Errorf("field %d is %q, want %q", fieldNr, got, want)
+		}
+	}
+	if err = BitmapUnpack(data[:len(data)-1], tmpl, unpacked); err == nil {
+		t.Error("unpacked truncated data without error")
+	}
+}
+
+func TestPackFieldOutsideBitmap(t *testing.T) {
+	msg := &BitmapMessage{BitmapMessageTemplate: benchmarkTemplate}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(129, "1")
+	if _, err := msg.Pack(); err == nil {
+		t.Error("packed field 129 without error")
+	}
+}
+
+func cloneTestMessage(t *testing.T) *BitmapMessage {
+	t.Helper()
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil),
+		NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)),
+	)}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0100)
+	msg.SetString(3, "000000")
+	msg.SetSubField(48, 1, "ABCD")
+	msg.SetString(55, string(mustHex(t, "82021980"+"7005"+"9F3602000A"+"9F360200FF"+"9F36020001")))
+	data, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	unpacked.Init()
+	if err = BitmapUnpack(data, tmpl, unpacked); err != nil {
+		t.Fatal(err)
+	}
+	return unpacked
+}
+
+//changeAll edits every part of a message which a copy could share with its original.
+func changeAll(msg *BitmapMessage) {
+	msg.SetString(3, "200000")
+	msg.SetSubField(48, 1, "WXYZ")
+	emv := msg.FieldValues[55]
+	emv.Tlvs[0].Value[0] = 0x39
+	emv.Tlvs[1].Tlvs[0].Value[1] = 0x0B
+	emv.Tlvs[3].Value[1] = 0xEE
+	emv.FieldValues[0x82] = FieldValue{Value: "\x00\x00"}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	original := cloneTestMessage(t)
+	want, err := original.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := original.Clone()
+	if packed, err := clone.Pack(); err != nil || string(packed) != string(want) {
+		t.Fatalf("clone packed %X %v, want %X", packed, err, want)
+	}
+	changeAll(clone)
+	if packed, err := original.Pack(); err != nil || string(packed) != string(want) {
+		t.Errorf("original changed with its clone, packed %X %v, want %X", packed, err, want)
+	}
+	if packed, _ := clone.Pack(); string(packed) == string(want) {
+		t.Error("clone packs unchanged after editing it")
+	}
+	tlvs := original.FieldValues[55].Tlvs
+	if tlvs[0].Value[0] != 0x19 || tlvs[1].Tlvs[0].Value[1] != 0x0A || tlvs[3].Value[1] != 0x01 {
+		t.Errorf("original data objects changed with its clone: %X %X %X", tlvs[0].Value, tlvs[1].Tlvs[0].Value, tlvs[3].Value)
+	}
+}
+
+func TestCopyFields(t *testing.T) {
+	original := cloneTestMessage(t)
+	want, err := original.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"BitmapMessage", "SyncMessage"} {
+		t.Run(name, func(t *testing.T) {
+			dst := &BitmapMessage{BitmapMessageTemplate: original.BitmapMessageTemplate}
+			dst.Init()
+			dst.SetMsgType(0x0110)
+			dst.SetString(11, "000042")
+			var src Message = original
+			if name == "SyncMessage" {
+				src = NewSyncMessage(original)
+			}
+			dst.CopyFields(src, 3, 11, 48, 55)
+			if value, _ := dst.GetString(11); value != "000042" {
+				t.Errorf("field not set in the source changed to %q", value)
+			}
+			if value, _ := dst.GetSubField(48, 1); value != "ABCD" {
+				t.Errorf("copied subfield 48.01 is %q", value)
+			}
+			if value, _ := dst.GetTagString(55, 0x9F36); value != "000A" {
+				t.Errorf("copied tag 9F36 is %q", value)
+			}
+			changeAll(dst)
+			if packed, err := original.Pack(); err != nil || string(packed) != string(want) {
+				t.Errorf("original changed with its copy, packed %X %v, want %X", packed, err, want)
+			}
+		})
+	}
+}
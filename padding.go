@@ -0,0 +1,122 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type justification int
+
+const (
+	//LeftJustified values are padded on the right.
+	LeftJustified justification = iota
+	//RightJustified values are padded on the left.
+	RightJustified
+)
+
+type overflow int
+
+const (
+	//OverflowError rejects values longer than the field.
+	OverflowError overflow = iota
+	//OverflowTruncate drops the characters furthest from the justified side.
+	OverflowTruncate
+)
+
+//Padding describes how values shorter than a fixed field are padded, and how longer values are handled.
+type Padding struct {
+	Justify  justification
+	Char     byte //Zero for the default of the field type, '0' for numeric and ' ' for alphanumeric fields
+	Trim     bool //Remove the padding when unpacking
+	Overflow overflow
+}
+
+//DefaultPadding returns the padding for a field type. Numeric fields are zero filled on the left,
+//binary fields are filled with zero bytes on the right and all others are space filled on the right.
+func DefaultPadding(t fieldType) Padding {
+	switch t {
+	case Numeric:
+		return Padding{Justify: RightJustified, Char: '0'}
+	case Binary:
+		return Padding{Justify: LeftJustified, Char: 0x00}
+	default:
+		return Padding{Justify: LeftJustified, Char: ' '}
+	}
+}
+
+//Pad pads or truncates value to size.
+func (p Padding) Pad(value string, size int) (string, error) {
+	if len(value) == size {
+		return value, nil
+	}
+	padded, err := p.appendPadded(make([]byte, 0, size), value, size)
+	return string(padded), err
+}
+
+func (p Padding) appendPadded(dst []byte, value string, size int) ([]byte, error) {
+	if len(value) > size {
+		if p.Overflow != OverflowTruncate {
+			return dst, errors.New(fmt.Sprint("Value of length ", len(value), " exceeds field size ", size))
+		}
+		if p.Justify == RightJustified {
+			return append(dst, value[len(value)-size:]...), nil
+		}
+		return append(dst, value[:size]...), nil
+	}
+	if p.Justify == LeftJustified {
+		dst = append(dst, value...)
+	}
+	for i := len(value); i < size; i++ {
+		dst = append(dst, p.Char)
+	}
+	if p.Justify == RightJustified {
+		dst = append(dst, value...)
+	}
+	return dst, nil
+}
+
+//Unpad removes padding from an unpacked value if Trim is set. A numeric value of all zeros is kept as a single zero.
+func (p Padding) Unpad(value string) string {
+	if !p.Trim {
+		return value
+	}
+	pad := string(p.Char)
+	var trimmed string
+	if p.Justify == RightJustified {
+		trimmed = strings.TrimLeft(value, pad)
+	} else {
+		trimmed = strings.TrimRight(value, pad)
+	}
+	if trimmed == "" && value != "" && p.Char == '0' {
+		return "0"
+	}
+	return trimmed
+}
+
+//GetPadding returns the padding set for the field, or the default for its type. A padding set without a Char pads with
+//the default character of the field type rather than NUL.
+func (f *BitmapMessageField) GetPadding() Padding {
+	if f.Padding == nil {
+		return DefaultPadding(f.Type)
+	}
+	padding := *f.Padding
+	if padding.Char == 0 {
+		padding.Char = DefaultPadding(f.Type).Char
+	}
+	return padding
+}
+
+//SetPadding overrides the default padding for the field type.
+func (f *BitmapMessageField) SetPadding(padding Padding) {
+	f.Padding = &padding
+}
+
+//WithPadding sets the padding of a field when declaring a template, e.g.
+//	go8583.WithPadding(go8583.NewFixedField(41, "terminalId", 8, go8583.AlphaNumericSpecial), go8583.Padding{Char: ' ', Trim: true})
+func WithPadding(field Field, padding Padding) Field {
+	if f, ok := field.(interface{ SetPadding(Padding) }); ok {
+		f.SetPadding(padding)
+	}
+	return field
+}
@@ -0,0 +1,35 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func TestPaddingDefaultChar(t *testing.T) {
+	tests := []struct {
+		field    Field
+		value    string
+		want     string
+		unpadded string
+	}{
+		{WithPadding(NewFixedField(4, "amount", 6, Numeric), Padding{Justify: RightJustified, Trim: true}), "12", "000012", "12"},
+		{WithPadding(NewFixedField(41, "terminalId", 8, AlphaNumericSpecial), Padding{Trim: true}), "T1", "T1      ", "T1"},
+		{WithPadding(NewFixedField(52, "pinData", 4, Binary), Padding{}), "\x01", "\x01\x00\x00\x00", "\x01\x00\x00\x00"},
+		{WithPadding(NewFixedField(43, "name", 4, AlphaNumericSpecial), Padding{Char: '*'}), "AB", "AB**", "AB**"},
+	}
+	for _, test := range tests {
+		data, err := test.field.PackField(FieldValue{Value: test.value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.want {
+			t.Errorf("field %d packed %q, want %q", test.field.GetFieldNumber(), data, test.want)
+		}
+		_, value, err := test.field.UnpackField(0, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.Value != test.unpadded {
+			t.Errorf("field %d unpacked %q, want %q", test.field.GetFieldNumber(), value.Value, test.unpadded)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//subFieldParser is implemented by composite fields whose subfields are not addressed by decimal numbers, such as EMV tags in hex.
+type subFieldParser interface {
+	ParseSubField(s string) (int, error)
+}
+
+//subFieldFormatter is the counterpart of subFieldParser, formatting a subfield number as it is addressed.
+type subFieldFormatter interface {
+	FormatSubField(subFieldNr int) string
+}
+
+//ParseSubField parses an EMV tag in hex, e.g. the 9F26 in 55.9F26.
+func (f *emvField) ParseSubField(s string) (int, error) {
+	return ParseEmvTag(s)
+}
+
+//ParseSubField parses a tag as it appears on the wire, e.g. the 01 in 48.01.
+func (f *tlvField) ParseSubField(s string) (int, error) {
+	return f.Format.ParseTag(s)
+}
+
+//FormatSubField formats an EMV tag in hex.
+func (f *emvField) FormatSubField(subFieldNr int) string {
+	return FormatEmvTag(subFieldNr)
+}
+
+//FormatSubField formats a subfield number as its tag appears on the wire.
+func (f *tlvField) FormatSubField(subFieldNr int) string {
+	tag, err := f.Format.FormatTag(subFieldNr)
+	if err != nil {
+		return strconv.Itoa(subFieldNr)
+	}
+	return tag
+}
+
+//ParsePath resolves a path such as "127.22.3" or "55.9F26" to field numbers, using the template to interpret each level.
+func (t *BitmapMessageTemplate) ParsePath(path string) (fieldNrs []int, err error) {
+	parts := strings.Split(path, ".")
+	var tmpl MessageTemplate = t
+	var parser subFieldParser
+	for _, part := range parts {
+		var nr int
+		if parser != nil {
+			nr, err = parser.ParseSubField(part)
+		} else {
+			nr, err = strconv.Atoi(part)
+		}
+		if err != nil || part == "" {
+			return nil, errors.New(fmt.Sprint("Invalid field path ", path))
+		}
+		fieldNrs = append(fieldNrs, nr)
+
+		parser = nil
+		if tmpl == nil {
+			continue
+		}
+		field, err := tmpl.GetFieldDef(nr)
+		if err != nil {
+			tmpl = nil
+			continue
+		}
+		tmpl, _ = field.(MessageTemplate)
+		parser, _ = field.(subFieldParser)
+	}
+	return fieldNrs, nil
+}
+
+//Get returns the value at a path such as "127.22.3".
+func (m *BitmapMessage) Get(path string) (value string, isSet bool) {
+	fieldNrs, err := m.ParsePath(path)
+	if err != nil {
+		return "", false
+	}
+	fieldValue, isSet := getPath(m.FieldValues, fieldNrs)
+	return fieldValue.Value, isSet
+}
+
+//Has returns true if the field or subfield at the path is set.
+func (m *BitmapMessage) Has(path string) bool {
+	_, isSet := m.Get(path)
+	return isSet
+}
+
+//Set sets the value at a path such as "48.01", creating any intermediate subfields.
+func (m *BitmapMessage) Set(path string, value string) error {
+	return m.SetPathValue(path, FieldValue{Value: value})
+}
+
+//GetPathValue returns the value at a path with any subfields, for copying composite fields and subfields.
+func (m *BitmapMessage) GetPathValue(path string) (value FieldValue, isSet bool) {
+	fieldNrs, err := m.ParsePath(path)
+	if err != nil {
+		return FieldValue{}, false
+	}
+	return getPath(m.FieldValues, fieldNrs)
+}
+
+//SetPathValue sets the value at a path with any subfields, creating any intermediate subfields. Every field on the path
+//but the last must be a composite field.
+func (m *BitmapMessage) SetPathValue(path string, value FieldValue) error {
+	fieldNrs, err := m.ParsePath(path)
+	if err != nil {
+		return err
+	}
+	if fieldNrs[0] == 1 {
+		return errors.New("Field 1 is the secondary bitmap and cannot be set")
+	}
+	if len(fieldNrs) == 1 {
+		m.SetField(fieldNrs[0], value)
+		return nil
+	}
+	tmpl, err := subTemplate(m.BitmapMessageTemplate, fieldNrs[0])
+	if err != nil {
+		return errors.New(fmt.Sprint("Invalid field path ", path, ": ", err))
+	}
+	fieldValue := m.FieldValues[fieldNrs[0]]
+	if fieldValue.FieldValues == nil {
+		fieldValue.FieldValues = make(map[int]FieldValue)
+	}
+	if err = setPath(tmpl, fieldValue.FieldValues, fieldNrs[1:], value); err != nil {
+		return errors.New(fmt.Sprint("Invalid field path ", path, ": ", err))
+	}
+	m.SetField(fieldNrs[0], fieldValue)
+	return nil
+}
+
+//Unset removes the field or subfield at the path. Composites left with no subfields are removed as well.
+func (m *BitmapMessage) Unset(path string) error {
+	fieldNrs, err := m.ParsePath(path)
+	if err != nil {
+		return err
+	}
+	unsetPath(m.FieldValues, fieldNrs)
+	return nil
+}
+
+func getPath(values map[int]FieldValue, fieldNrs []int) (FieldValue, bool) {
+	value, isSet := values[fieldNrs[0]]
+	if !isSet || len(fieldNrs) == 1 {
+		return value, isSet
+	}
+	if value.FieldValues == nil {
+		return FieldValue{}, false
+	}
+	return getPath(value.FieldValues, fieldNrs[1:])
+}
+
+func setPath(tmpl MessageTemplate, values map[int]FieldValue, fieldNrs []int, value FieldValue) error {
+	if len(fieldNrs) == 1 {
+		values[fieldNrs[0]] = value
+		return nil
+	}
+	subTmpl, err := subTemplate(tmpl, fieldNrs[0])
+	if err != nil {
+		return err
+	}
+	fieldValue := values[fieldNrs[0]]
+	if fieldValue.FieldValues == nil {
+		fieldValue.FieldValues = make(map[int]FieldValue)
+	}
+	if err = setPath(subTmpl, fieldValue.FieldValues, fieldNrs[1:], value); err != nil {
+		return err
+	}
+	values[fieldNrs[0]] = fieldValue
+	return nil
+}
+
+//subTemplate returns the definitions of the subfields of a composite field, or an error if the field has no subfields.
+func subTemplate(tmpl MessageTemplate, fieldNr int) (MessageTemplate, error) {
+	field, err := tmpl.GetFieldDef(fieldNr)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := field.(compositeField); ok {
+		if subTmpl, ok := field.(MessageTemplate); ok {
+			return subTmpl, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprint("Field ", fieldNr, " is not a composite field"))
+}
+
+func unsetPath(values map[int]FieldValue, fieldNrs []int) {
+	if len(fieldNrs) == 1 {
+		delete(values, fieldNrs[0])
+		return
+	}
+	fieldValue, isSet := values[fieldNrs[0]]
+	if !isSet || fieldValue.FieldValues == nil {
+		return
+	}
+	unsetPath(fieldValue.FieldValues, fieldNrs[1:])
+	if len(fieldValue.FieldValues) == 0 {
+		delete(values, fieldNrs[0])
+	}
+}
@@ -0,0 +1,62 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func pathTestMessage() *BitmapMessage {
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil),
+		NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)),
+		NewBitmapField(127, "private", NewVariableFieldPackerUnpacker(6), []Field{
+			NewPositionalField(22, "positional", 10, NewVariableFieldPackerUnpacker(2), []Field{
+				NewFixedField(1, "first", 4, Numeric),
+				NewFixedField(2, "second", 6, Numeric),
+			}),
+			NewFixedField(3, "fixed", 2, Numeric),
+		}),
+	)}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	return msg
+}
+
+func TestSetPath(t *testing.T) {
+	msg := pathTestMessage()
+	for path, value := range map[string]string{"3": "000000", "48.01": "ABC", "55.9F26": "\x01", "127.22.2": "123456", "127.3": "12"} {
+		if err := msg.Set(path, value); err != nil {
+			t.Fatalf("setting %s: %v", path, err)
+		}
+		if got, ok := msg.Get(path); !ok || got != value {
+			t.Errorf("%s is %q %v, want %q", path, got, ok, value)
+		}
+	}
+}
+
+func TestSetPathNotComposite(t *testing.T) {
+	msg := pathTestMessage()
+	msg.SetString(3, "000000")
+	for _, path := range []string{"3.1", "127.3.1", "127.22.2.1", "48.01.02", "2.1"} {
+		if err := msg.Set(path, "1"); err == nil {
+			t.Errorf("set %s without error", path)
+		}
+	}
+	if value, ok := msg.GetPathValue("3"); !ok || value.Value != "000000" || value.FieldValues != nil {
+		t.Errorf("field 3 changed to %+v", value)
+	}
+	if msg.Has("127") {
+		t.Error("field 127 set by a failed path")
+	}
+}
+
+func TestSetBitmapField(t *testing.T) {
+	msg := pathTestMessage()
+	if err := msg.Set("1", "1"); err == nil {
+		t.Error("set field 1 without error")
+	}
+	msg.SetString(1, "1")
+	if msg.Has("1") {
+		t.Error("field 1 set")
+	}
+}
@@ -0,0 +1,71 @@
+package pin
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"errors"
+)
+
+//Key encrypts and decrypts PIN blocks. Implement it to have an HSM hold the zone key, or use NewTdesKey and NewAesKey in software.
+type Key interface {
+	//BlockSize returns the cipher block size, 8 for TDES and 16 for AES.
+	BlockSize() int
+	Encrypt(data []byte) ([]byte, error)
+	Decrypt(data []byte) ([]byte, error)
+}
+
+type blockKey struct {
+	block cipher.Block
+}
+
+//NewTdesKey creates a triple DES key from 16 (double length) or 24 (triple length) bytes.
+func NewTdesKey(key []byte) (Key, error) {
+	switch len(key) {
+	case 16:
+		key = append(append([]byte{}, key...), key[:8]...)
+	case 24:
+	default:
+		return nil, errors.New("TDES key must be 16 or 24 bytes")
+	}
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &blockKey{block}, nil
+}
+
+//NewAesKey creates an AES key from 16, 24 or 32 bytes.
+func NewAesKey(key []byte) (Key, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &blockKey{block}, nil
+}
+
+func (k *blockKey) BlockSize() int {
+	return k.block.BlockSize()
+}
+
+//Encrypt encrypts data in ECB mode. data must be a whole number of blocks.
+func (k *blockKey) Encrypt(data []byte) ([]byte, error) {
+	return k.crypt(data, k.block.Encrypt)
+}
+
+//Decrypt decrypts data in ECB mode. data must be a whole number of blocks.
+func (k *blockKey) Decrypt(data []byte) ([]byte, error) {
+	return k.crypt(data, k.block.Decrypt)
+}
+
+func (k *blockKey) crypt(data []byte, fn func(dst, src []byte)) ([]byte, error) {
+	size := k.block.BlockSize()
+	if len(data) == 0 || len(data)%size != 0 {
+		return nil, errors.New("Data is not a whole number of blocks")
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += size {
+		fn(out[i:i+size], data[i:i+size])
+	}
+	return out, nil
+}
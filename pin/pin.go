@@ -0,0 +1,247 @@
+//Package pin builds, parses, encrypts and translates ISO 9564 PIN blocks, formats 0, 1, 3 and 4, as carried in DE52.
+package pin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/doswell/go8583/util"
+)
+
+//Format is an ISO 9564 PIN block format.
+type Format int
+
+const (
+	//Format0 is the PIN XORed with the PAN (ANSI X9.8), padded with F.
+	Format0 Format = 0
+	//Format1 is the PIN padded with random digits, with no PAN.
+	Format1 Format = 1
+	//Format3 is the PIN XORed with the PAN, padded with random digits A to F.
+	Format3 Format = 3
+	//Format4 is the 16 byte AES PIN block, enciphered twice with the PAN between.
+	Format4 Format = 4
+)
+
+const (
+	minPinLength = 4
+	maxPinLength = 12
+)
+
+//Build creates a clear PIN block of format 0, 1 or 3. Format 4 blocks only exist encrypted, see Encrypt.
+func Build(format Format, pin string, pan string) ([]byte, error) {
+	if format == Format4 {
+		return nil, errors.New("Format 4 PIN blocks must be built with Encrypt")
+	}
+	pinField, err := buildPinField(format, pin)
+	if err != nil {
+		return nil, err
+	}
+	if format == Format1 {
+		return pinField, nil
+	}
+	panField, err := buildPanField(pan)
+	if err != nil {
+		return nil, err
+	}
+	return xor(pinField, panField), nil
+}
+
+//Parse returns the PIN from a clear PIN block of format 0, 1 or 3.
+func Parse(format Format, block []byte, pan string) (string, error) {
+	if format == Format4 {
+		return "", errors.New("Format 4 PIN blocks must be parsed with Decrypt")
+	}
+	if len(block) != 8 {
+		return "", errors.New("PIN block must be 8 bytes")
+	}
+	pinField := block
+	if format != Format1 {
+		panField, err := buildPanField(pan)
+		if err != nil {
+			return "", err
+		}
+		pinField = xor(block, panField)
+	}
+	return parsePinField(format, pinField)
+}
+
+//Encrypt creates a PIN block encrypted under key. Formats 0, 1 and 3 need a TDES key, format 4 an AES key.
+func Encrypt(key Key, format Format, pin string, pan string) ([]byte, error) {
+	if err := checkKey(key, format); err != nil {
+		return nil, err
+	}
+	if format != Format4 {
+		block, err := Build(format, pin, pan)
+		if err != nil {
+			return nil, err
+		}
+		return key.Encrypt(block)
+	}
+
+	pinField, err := buildPinField(format, pin)
+	if err != nil {
+		return nil, err
+	}
+	panField, err := buildFormat4PanField(pan)
+	if err != nil {
+		return nil, err
+	}
+	intermediate, err := key.Encrypt(pinField)
+	if err != nil {
+		return nil, err
+	}
+	return key.Encrypt(xor(intermediate, panField))
+}
+
+//Decrypt returns the PIN from a PIN block encrypted under key.
+func Decrypt(key Key, format Format, block []byte, pan string) (string, error) {
+	if err := checkKey(key, format); err != nil {
+		return "", err
+	}
+	if len(block) != key.BlockSize() {
+		return "", errors.New(fmt.Sprint("PIN block must be ", key.BlockSize(), " bytes"))
+	}
+	clear, err := key.Decrypt(block)
+	if err != nil {
+		return "", err
+	}
+	if format != Format4 {
+		return Parse(format, clear, pan)
+	}
+
+	panField, err := buildFormat4PanField(pan)
+	if err != nil {
+		return "", err
+	}
+	pinField, err := key.Decrypt(xor(clear, panField))
+	if err != nil {
+		return "", err
+	}
+	return parsePinField(format, pinField)
+}
+
+//Translate decrypts a PIN block under one key and format and encrypts it under another, as when a PIN moves between zones.
+func Translate(from Key, fromFormat Format, to Key, toFormat Format, block []byte, pan string) ([]byte, error) {
+	pin, err := Decrypt(from, fromFormat, block, pan)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(to, toFormat, pin, pan)
+}
+
+func checkKey(key Key, format Format) error {
+	blockSize := 8
+	if format == Format4 {
+		blockSize = 16
+	}
+	if key.BlockSize() != blockSize {
+		return errors.New(fmt.Sprint("Format ", int(format), " PIN blocks need a key with a ", blockSize, " byte block"))
+	}
+	return nil
+}
+
+//buildPinField creates the plain text PIN field: the format, PIN length, PIN and fill.
+func buildPinField(format Format, pin string) ([]byte, error) {
+	if len(pin) < minPinLength || len(pin) > maxPinLength || !util.IsDigits(pin) {
+		return nil, errors.New(fmt.Sprint("PIN must be ", minPinLength, " to ", maxPinLength, " digits"))
+	}
+	var size int
+	switch format {
+	case Format0, Format1, Format3:
+		size = 16
+	case Format4:
+		size = 32
+	default:
+		return nil, errors.New(fmt.Sprint("Unsupported PIN block format ", int(format)))
+	}
+
+	digits := make([]byte, 0, size)
+	digits = append(digits, hexDigit(byte(format)), hexDigit(byte(len(pin))))
+	digits = append(digits, pin...)
+	random := make([]byte, size)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	for i := len(digits); i < size; i++ {
+		switch {
+		case format == Format0:
+			digits = append(digits, 'F')
+		case format == Format1:
+			digits = append(digits, hexDigit(random[i]&0x0F))
+		case format == Format3:
+			digits = append(digits, hexDigit(10+random[i]%6))
+		case i < 16:
+			digits = append(digits, 'A')
+		default:
+			digits = append(digits, hexDigit(random[i]&0x0F))
+		}
+	}
+	return hex.DecodeString(string(digits))
+}
+
+//parsePinField checks the control and fill digits of a plain text PIN field and returns the PIN.
+func parsePinField(format Format, pinField []byte) (string, error) {
+	digits := strings.ToUpper(hex.EncodeToString(pinField))
+	if digits[0] != hexDigit(byte(format)) {
+		return "", errors.New(fmt.Sprint("PIN block is not format ", int(format)))
+	}
+	length := strings.IndexByte("0123456789ABCDEF", digits[1])
+	if length < minPinLength || length > maxPinLength || !util.IsDigits(digits[2:2+length]) {
+		return "", errors.New("Invalid PIN length or digits")
+	}
+	fill := digits[2+length:]
+	switch format {
+	case Format0:
+		if strings.Trim(fill, "F") != "" {
+			return "", errors.New("Invalid PIN block fill")
+		}
+	case Format3:
+		if strings.Trim(fill, "ABCDEF") != "" {
+			return "", errors.New("Invalid PIN block fill")
+		}
+	case Format4:
+		if strings.Trim(fill[:14-length], "A") != "" {
+			return "", errors.New("Invalid PIN block fill")
+		}
+	}
+	return digits[2 : 2+length], nil
+}
+
+//buildPanField creates the account number field of formats 0 and 3, the rightmost 12 PAN digits excluding the check digit.
+func buildPanField(pan string) ([]byte, error) {
+	if len(pan) < 2 || !util.IsDigits(pan) {
+		return nil, errors.New("PAN must be digits")
+	}
+	account := pan[:len(pan)-1]
+	if len(account) > 12 {
+		account = account[len(account)-12:]
+	}
+	return hex.DecodeString(strings.Repeat("0", 16-len(account)) + account)
+}
+
+//buildFormat4PanField creates the 16 byte account number field of format 4, the PAN length less 12 followed by the whole PAN.
+func buildFormat4PanField(pan string) ([]byte, error) {
+	if len(pan) > 19 || !util.IsDigits(pan) {
+		return nil, errors.New("PAN must be up to 19 digits")
+	}
+	if len(pan) < 12 {
+		pan = strings.Repeat("0", 12-len(pan)) + pan
+	}
+	digits := string(hexDigit(byte(len(pan)-12))) + pan
+	return hex.DecodeString(digits + strings.Repeat("0", 32-len(digits)))
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func hexDigit(b byte) byte {
+	return "0123456789ABCDEF"[b&0x0F]
+}
@@ -0,0 +1,140 @@
+package pin
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBuildFormat0(t *testing.T) {
+	tests := []struct {
+		pin, pan, block string
+	}{
+		{"1234", "43219876543210987", "0412AC89ABCDEF67"},
+		{"1234", "4111111111111111", "041225EEEEEEEEEE"},
+		{"123456789012", "5500000000000004", "0C123456789012FF"},
+	}
+	for _, test := range tests {
+		block, err := Build(Format0, test.pin, test.pan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.ToUpper(hex.EncodeToString(block)); got != test.block {
+			t.Errorf("PIN %s PAN %s built %s, want %s", test.pin, test.pan, got, test.block)
+		}
+		if pin, err := Parse(Format0, block, test.pan); err != nil || pin != test.pin {
+			t.Errorf("parsed %s %v, want %s", pin, err, test.pin)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tdesKey, err := NewTdesKey(mustHex(t, "0123456789ABCDEFFEDCBA9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aesKey, err := NewAesKey(mustHex(t, "FEDCBA9876543210F1F1F1F1F1F1F1F1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format Format
+		key    Key
+		fill   string //Characters the clear PIN field may be filled with
+	}{
+		{Format0, tdesKey, "F"},
+		{Format1, tdesKey, "0123456789ABCDEF"},
+		{Format3, tdesKey, "ABCDEF"},
+		{Format4, aesKey, ""},
+	}
+	pan := "4012345678909"
+	for _, test := range tests {
+		for _, pin := range []string{"1234", "12345678", "123456789012"} {
+			if test.format != Format4 {
+				block, err := Build(test.format, pin, pan)
+				if err != nil {
+					t.Fatal(err)
+				}
+				clear := block
+				if test.format != Format1 {
+					panField, _ := buildPanField(pan)
+					clear = xor(block, panField)
+				}
+				digits := strings.ToUpper(hex.EncodeToString(clear))
+				if want := string(hexDigit(byte(test.format))) + string(hexDigit(byte(len(pin)))) + pin; digits[:2+len(pin)] != want {
+					t.Errorf("format %d PIN field %s does not start %s", test.format, digits, want)
+				}
+				if strings.Trim(digits[2+len(pin):], test.fill) != "" {
+					t.Errorf("format %d PIN field %s is not filled with %s", test.format, digits, test.fill)
+				}
+			}
+			block, err := Encrypt(test.key, test.format, pin, pan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(block) != test.key.BlockSize() {
+				t.Errorf("format %d block is %d bytes", test.format, len(block))
+			}
+			if got, err := Decrypt(test.key, test.format, block, pan); err != nil || got != pin {
+				t.Errorf("format %d decrypted %s %v, want %s", test.format, got, err, pin)
+			}
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	from, _ := NewTdesKey(mustHex(t, "0123456789ABCDEFFEDCBA9876543210"))
+	to, _ := NewAesKey(mustHex(t, "FEDCBA9876543210F1F1F1F1F1F1F1F1"))
+	pan := "4012345678909"
+	block, err := Encrypt(from, Format0, "1234", pan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translated, err := Translate(from, Format0, to, Format4, block, pan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin, err := Decrypt(to, Format4, translated, pan); err != nil || pin != "1234" {
+		t.Errorf("decrypted %s %v, want 1234", pin, err)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	tdesKey, _ := NewTdesKey(mustHex(t, "0123456789ABCDEFFEDCBA9876543210"))
+	aesKey, _ := NewAesKey(mustHex(t, "FEDCBA9876543210F1F1F1F1F1F1F1F1"))
+	pan := "4012345678909"
+	for _, pin := range []string{"123", "1234567890123", "12a4"} {
+		if _, err := Build(Format0, pin, pan); err == nil {
+			t.Errorf("built PIN %s without error", pin)
+		}
+	}
+	if _, err := Build(Format0, "1234", "40123X"); err == nil {
+		t.Error("built with an invalid PAN without error")
+	}
+	if _, err := Build(Format4, "1234", pan); err == nil {
+		t.Error("built a clear format 4 block without error")
+	}
+	if _, err := Encrypt(aesKey, Format0, "1234", pan); err == nil {
+		t.Error("encrypted format 0 under an AES key without error")
+	}
+	if _, err := Encrypt(tdesKey, Format4, "1234", pan); err == nil {
+		t.Error("encrypted format 4 under a TDES key without error")
+	}
+	if _, err := Parse(Format0, mustHex(t, "0412AC89ABCDEF67"), pan); err == nil {
+		t.Error("parsed a block for another PAN without error")
+	}
+	for _, block := range []string{"0412AC89ABCDEF60", "1412AC89ABCDEF67"} {
+		if _, err := Parse(Format0, mustHex(t, block), "43219876543210987"); err == nil {
+			t.Errorf("parsed %s with the wrong fill or format without error", block)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package go8583
+
+import (
+	"errors"
+
+	"github.com/doswell/go8583/pin"
+)
+
+//GetPan returns the PAN from DE2, or from the track 2 data in DE35 when DE2 is not set.
+func (m *BitmapMessage) GetPan() (pan string, isSet bool) {
+	if pan, isSet = m.GetString(2); isSet {
+		return pan, true
+	}
+	return track2Pan(m)
+}
+
+//SetPinBlock encrypts the PIN under key in the given format and sets DE52, using the PAN of the message.
+func (m *BitmapMessage) SetPinBlock(key pin.Key, format pin.Format, pinValue string) error {
+	pan, _ := m.GetPan()
+	block, err := pin.Encrypt(key, format, pinValue, pan)
+	if err != nil {
+		return err
+	}
+	m.SetBytes(52, block)
+	return nil
+}
+
+//GetPin decrypts the PIN block in DE52 under key, using the PAN of the message.
+func (m *BitmapMessage) GetPin(key pin.Key, format pin.Format) (string, error) {
+	block, isSet := m.GetBytes(52)
+	if !isSet {
+		return "", errors.New("PIN block field 52 is not set")
+	}
+	pan, _ := m.GetPan()
+	return pin.Decrypt(key, format, block, pan)
+}
+
+//TranslatePinBlock re-encrypts the PIN block in DE52 from one zone key and format to another.
+func (m *BitmapMessage) TranslatePinBlock(from pin.Key, fromFormat pin.Format, to pin.Key, toFormat pin.Format) error {
+	block, isSet := m.GetBytes(52)
+	if !isSet {
+		return errors.New("PIN block field 52 is not set")
+	}
+	pan, _ := m.GetPan()
+	translated, err := pin.Translate(from, fromFormat, to, toFormat, block, pan)
+	if err != nil {
+		return err
+	}
+	m.SetBytes(52, translated)
+	return nil
+}
@@ -0,0 +1,34 @@
+package go8583
+
+import "sync"
+
+//MessagePool reuses messages of a single template to reduce allocations when processing high volumes.
+type MessagePool struct {
+	tmpl *BitmapMessageTemplate
+	pool sync.Pool
+}
+
+//NewMessagePool creates a pool of messages using the template.
+func NewMessagePool(tmpl *BitmapMessageTemplate) *MessagePool {
+	p := &MessagePool{tmpl: tmpl}
+	p.pool.New = func() interface{} {
+		msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+		msg.Init()
+		return msg
+	}
+	return p
+}
+
+//Get returns an empty message from the pool.
+func (p *MessagePool) Get() *BitmapMessage {
+	return p.pool.Get().(*BitmapMessage)
+}
+
+//Put resets the message and returns it to the pool. The message must not be used afterwards.
+func (p *MessagePool) Put(msg *BitmapMessage) {
+	if msg.BitmapMessageTemplate != p.tmpl {
+		return
+	}
+	msg.Reset()
+	p.pool.Put(msg)
+}
@@ -0,0 +1,47 @@
+package go8583
+
+import (
+	"sync"
+	"testing"
+)
+
+//TestConcurrentPackUnpack packs and unpacks on one frozen template with pooled messages. Run with go test -race.
+func TestConcurrentPackUnpack(t *testing.T) {
+	pool := NewMessagePool(benchmarkTemplate)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 0, 512)
+			for i := 0; i < 200; i++ {
+				msg := pool.Get()
+				fillBenchmarkMessage(msg)
+				var err error
+				if buf, err = msg.AppendPack(buf[:0]); err != nil {
+					errs <- err
+					return
+				}
+				pool.Put(msg)
+
+				msg = pool.Get()
+				if err = BitmapUnpack(buf, benchmarkTemplate, msg); err != nil {
+					errs <- err
+					return
+				}
+				for fieldNr, want := range benchmarkValues {
+					if got, _ := msg.GetString(fieldNr); got != want {
+						t.Errorf("field %d is %q, want %q", fieldNr, got, want)
+					}
+				}
+				pool.Put(msg)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
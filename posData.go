@@ -0,0 +1,224 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/doswell/go8583/util"
+)
+
+//TerminalAttendance is position 1 of the POS data, DE61.
+type TerminalAttendance string
+
+const (
+	AttendedTerminal   TerminalAttendance = "0"
+	UnattendedTerminal TerminalAttendance = "1"
+	NoTerminal         TerminalAttendance = "2"
+)
+
+var terminalAttendanceLookup = map[TerminalAttendance]string{
+	AttendedTerminal:   "Attended",
+	UnattendedTerminal: "Unattended",
+	NoTerminal:         "No terminal",
+}
+
+//String returns the name of the attendance, or its code if it has no name.
+func (t TerminalAttendance) String() string {
+	return lookupName(terminalAttendanceLookup[t], string(t))
+}
+
+//CardholderPresence is position 4 of the POS data.
+type CardholderPresence string
+
+const (
+	CardholderPresent         CardholderPresence = "0"
+	CardholderNotPresent      CardholderPresence = "1"
+	CardholderMailOrder       CardholderPresence = "2"
+	CardholderTelephone       CardholderPresence = "3"
+	CardholderRecurring       CardholderPresence = "4" //Standing order or recurring payment
+	CardholderElectronicOrder CardholderPresence = "5" //E-commerce
+)
+
+var cardholderPresenceLookup = map[CardholderPresence]string{
+	CardholderPresent:         "Cardholder present",
+	CardholderNotPresent:      "Cardholder not present",
+	CardholderMailOrder:       "Mail order",
+	CardholderTelephone:       "Telephone order",
+	CardholderRecurring:       "Recurring",
+	CardholderElectronicOrder: "Electronic order",
+}
+
+//String returns the name of the presence, or its code if it has no name.
+func (c CardholderPresence) String() string {
+	return lookupName(cardholderPresenceLookup[c], string(c))
+}
+
+//InputCapability is position 11 of the POS data: how the terminal can read cards.
+type InputCapability string
+
+const (
+	InputCapabilityUnknown     InputCapability = "0"
+	InputNoTerminal            InputCapability = "1"
+	InputMagstripe             InputCapability = "2"
+	InputContactless           InputCapability = "3"
+	InputContactlessMagstripe  InputCapability = "4"
+	InputChip                  InputCapability = "5" //Chip and magnetic stripe
+	InputKeyEntry              InputCapability = "6"
+	InputMagstripeKeyEntry     InputCapability = "7"
+	InputMagstripeKeyEntryChip InputCapability = "8"
+	InputMagstripeChip         InputCapability = "9"
+)
+
+var inputCapabilityLookup = map[InputCapability]string{
+	InputCapabilityUnknown:     "Input capability unknown",
+	InputNoTerminal:            "No terminal",
+	InputMagstripe:             "Magstripe",
+	InputContactless:           "Contactless",
+	InputContactlessMagstripe:  "Contactless magstripe",
+	InputChip:                  "Chip",
+	InputKeyEntry:              "Key entry",
+	InputMagstripeKeyEntry:     "Magstripe and key entry",
+	InputMagstripeKeyEntryChip: "Magstripe, key entry and chip",
+	InputMagstripeChip:         "Magstripe and chip",
+}
+
+//String returns the name of the capability, or its code if it has no name.
+func (i InputCapability) String() string {
+	return lookupName(inputCapabilityLookup[i], string(i))
+}
+
+//posDataMinSize is the length of the mandatory positions of the POS data, which may be followed by the authorization
+//life cycle, country code and postal code.
+const posDataMinSize = 11
+
+//posDataMaxSize is the length of the POS data with all optional parts, the postal code being at most 10 characters.
+const posDataMaxSize = 26
+
+//PosData is DE61 in the Mastercard layout of eleven single digit positions, followed by the optional authorization life
+//cycle n2, country code n3 and postal code ans..10. Positions 2 and 9 are reserved and formatted as 0. Networks which
+//lay DE61 out differently need their own type.
+type PosData struct {
+	TerminalAttendance     TerminalAttendance
+	TerminalLocation       string //Position 3, e.g. 0 on premises, 1 off premises
+	CardholderPresence     CardholderPresence
+	CardPresent            bool   //Position 5
+	CardCaptureCapable     bool   //Position 6
+	TransactionStatus      string //Position 7, e.g. 0 normal, 4 preauthorized
+	TransactionSecurity    string //Position 8, e.g. 0 no security concern
+	CatLevel               string //Position 10, the cardholder activated terminal level
+	InputCapability        InputCapability
+	AuthorizationLifeCycle string
+	CountryCode            string
+	PostalCode             string
+}
+
+//ParsePosData reads a POS data value in the Mastercard layout. The life cycle and country code, when present, must be
+//complete and numeric.
+func ParsePosData(value string) (PosData, error) {
+	if len(value) < posDataMinSize || len(value) > posDataMaxSize || !util.IsDigits(value[:posDataMinSize]) {
+		return PosData{}, errors.New(fmt.Sprint("Invalid POS data ", value))
+	}
+	p := PosData{
+		TerminalAttendance:  TerminalAttendance(value[0:1]),
+		TerminalLocation:    value[2:3],
+		CardholderPresence:  CardholderPresence(value[3:4]),
+		CardPresent:         value[4] == '0',
+		CardCaptureCapable:  value[5] == '1',
+		TransactionStatus:   value[6:7],
+		TransactionSecurity: value[7:8],
+		CatLevel:            value[9:10],
+		InputCapability:     InputCapability(value[10:11]),
+	}
+	rest := value[posDataMinSize:]
+	for _, part := range []struct {
+		value *string
+		size  int
+	}{{&p.AuthorizationLifeCycle, 2}, {&p.CountryCode, 3}} {
+		if rest == "" {
+			return p, nil
+		}
+		if len(rest) < part.size || !util.IsDigits(rest[:part.size]) {
+			return PosData{}, errors.New(fmt.Sprint("Invalid POS data ", value))
+		}
+		*part.value, rest = rest[:part.size], rest[part.size:]
+	}
+	p.PostalCode = rest
+	return p, nil
+}
+
+//Format returns the field value of the POS data. The optional parts are included up to the last one set, earlier
+//unset parts being zero filled.
+func (p PosData) Format() (string, error) {
+	buf := new(strings.Builder)
+	for _, position := range []string{string(p.TerminalAttendance), "0", p.TerminalLocation, string(p.CardholderPresence),
+		boolDigit(!p.CardPresent), boolDigit(p.CardCaptureCapable), p.TransactionStatus, p.TransactionSecurity, "0",
+		p.CatLevel, string(p.InputCapability)} {
+		if position == "" {
+			position = "0"
+		}
+		if len(position) != 1 || !util.IsDigits(position) {
+			return "", errors.New(fmt.Sprint("Invalid POS data position ", position))
+		}
+		buf.WriteString(position)
+	}
+	if len(p.AuthorizationLifeCycle) > 2 || len(p.CountryCode) > 3 || len(p.PostalCode) > 10 ||
+		!util.IsDigits(p.AuthorizationLifeCycle) || !util.IsDigits(p.CountryCode) {
+		return "", errors.New(fmt.Sprint("Invalid POS data life cycle ", p.AuthorizationLifeCycle, ", country code ",
+			p.CountryCode, " or postal code ", p.PostalCode))
+	}
+	if p.AuthorizationLifeCycle != "" || p.CountryCode != "" || p.PostalCode != "" {
+		buf.WriteString(util.LeftPad2Len(p.AuthorizationLifeCycle, "0", 2))
+	}
+	if p.CountryCode != "" || p.PostalCode != "" {
+		buf.WriteString(util.LeftPad2Len(p.CountryCode, "0", 3))
+	}
+	buf.WriteString(p.PostalCode)
+	return buf.String(), nil
+}
+
+func (p PosData) String() string {
+	card := "card present"
+	if !p.CardPresent {
+		card = "card not present"
+	}
+	return fmt.Sprint(p.TerminalAttendance, ", ", p.CardholderPresence, ", ", card, ", ", p.InputCapability)
+}
+
+//GetPosData returns the POS data, DE61.
+func (m *BitmapMessage) GetPosData() (PosData, bool, error) {
+	value, ok := m.GetString(61)
+	if !ok {
+		return PosData{}, false, nil
+	}
+	p, err := ParsePosData(value)
+	return p, true, err
+}
+
+//SetPosData sets the POS data, DE61.
+func (m *BitmapMessage) SetPosData(p PosData) error {
+	value, err := p.Format()
+	if err != nil {
+		return err
+	}
+	m.SetString(61, value)
+	return nil
+}
+
+//NewPosDataField creates a POS data field, shown by name in String() output.
+func NewPosDataField(bitNumber int, name string) Field {
+	return &describedField{NewLllVarField(bitNumber, name, posDataMaxSize, AlphaNumericSpecial).(*BitmapMessageField), func(value string) string {
+		p, err := ParsePosData(value)
+		if err != nil {
+			return ""
+		}
+		return p.String()
+	}}
+}
+
+func boolDigit(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
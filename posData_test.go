@@ -0,0 +1,68 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func TestPosDataRoundTrip(t *testing.T) {
+	tests := []struct {
+		value      string
+		lifeCycle  string
+		country    string
+		postalCode string
+	}{
+		{"00000000005", "", "", ""},
+		{"0000000000501", "01", "", ""},
+		{"0000000000500840", "00", "840", ""},
+		{"000000000050084010001", "00", "840", "10001"},
+		{"1025100003603826SW1A 1AA", "03", "826", "SW1A 1AA"},
+	}
+	for _, test := range tests {
+		p, err := ParsePosData(test.value)
+		if err != nil {
+			t.Errorf("%s: %v", test.value, err)
+			continue
+		}
+		if p.AuthorizationLifeCycle != test.lifeCycle || p.CountryCode != test.country || p.PostalCode != test.postalCode {
+			t.Errorf("%s parsed as %+v", test.value, p)
+		}
+		if value, err := p.Format(); err != nil || value != test.value {
+			t.Errorf("%s formatted as %s %v", test.value, value, err)
+		}
+	}
+	p, _ := ParsePosData("10251000036")
+	if p.TerminalAttendance != UnattendedTerminal || p.TerminalLocation != "2" || p.CardholderPresence != CardholderElectronicOrder ||
+		p.CardPresent || p.CardCaptureCapable || p.CatLevel != "3" || p.InputCapability != InputKeyEntry {
+		t.Errorf("10251000036 parsed as %+v", p)
+	}
+}
+
+func TestPosDataInvalid(t *testing.T) {
+	for _, value := range []string{
+		"0000000000",
+		"000000000050",                //Incomplete life cycle
+		"0000000000500A40",            //Country code not numeric
+		"00000000005008",              //Incomplete country code
+		"0A000000005",                 //Position not numeric
+		"0000000000500840123456789AB", //Postal code too long
+	} {
+		if p, err := ParsePosData(value); err == nil {
+			t.Errorf("parsed invalid POS data %s as %+v", value, p)
+		}
+	}
+	for _, p := range []PosData{
+		{TerminalAttendance: "A"},
+		{TerminalLocation: "12"},
+		{AuthorizationLifeCycle: "123"},
+		{AuthorizationLifeCycle: "0A"},
+		{CountryCode: "8A0"},
+		{PostalCode: "12345678901"},
+	} {
+		if value, err := p.Format(); err == nil {
+			t.Errorf("formatted invalid POS data %+v as %s", p, value)
+		}
+	}
+	if value, err := (PosData{CountryCode: "36"}).Format(); err != nil || value != "0000100000000036" {
+		t.Errorf("zero filled country code formatted as %s %v", value, err)
+	}
+}
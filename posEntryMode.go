@@ -0,0 +1,177 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583/util"
+)
+
+//PanEntryMode is the first two digits of the POS entry mode, DE22: how the PAN was read.
+type PanEntryMode string
+
+const (
+	PanEntryUnknown              PanEntryMode = "00"
+	PanEntryManual               PanEntryMode = "01"
+	PanEntryMagstripe            PanEntryMode = "02"
+	PanEntryBarcode              PanEntryMode = "03"
+	PanEntryChip                 PanEntryMode = "05"
+	PanEntryContactless          PanEntryMode = "07"
+	PanEntryCredentialOnFile     PanEntryMode = "10"
+	PanEntryChipFallback         PanEntryMode = "80" //Magnetic stripe read after the chip failed
+	PanEntryECommerce            PanEntryMode = "81"
+	PanEntryMagstripeFull        PanEntryMode = "90" //Full unaltered track data
+	PanEntryContactlessMagstripe PanEntryMode = "91"
+)
+
+var panEntryModeLookup = map[PanEntryMode]string{
+	PanEntryUnknown:              "Unknown",
+	PanEntryManual:               "Manual",
+	PanEntryMagstripe:            "Magstripe",
+	PanEntryBarcode:              "Barcode",
+	PanEntryChip:                 "Chip",
+	PanEntryContactless:          "Contactless",
+	PanEntryCredentialOnFile:     "Credential on file",
+	PanEntryChipFallback:         "Chip fallback",
+	PanEntryECommerce:            "E-commerce",
+	PanEntryMagstripeFull:        "Magstripe full track",
+	PanEntryContactlessMagstripe: "Contactless magstripe",
+}
+
+//String returns the name of the entry mode, or its code if it has no name.
+func (p PanEntryMode) String() string {
+	return lookupName(panEntryModeLookup[p], string(p))
+}
+
+//PinCapability is the last digit of the POS entry mode: whether the terminal can accept a PIN.
+type PinCapability string
+
+const (
+	PinCapabilityUnknown PinCapability = "0"
+	PinCapable           PinCapability = "1"
+	NoPinCapability      PinCapability = "2"
+	PinPadInoperative    PinCapability = "8"
+)
+
+var pinCapabilityLookup = map[PinCapability]string{
+	PinCapabilityUnknown: "PIN capability unknown",
+	PinCapable:           "PIN capable",
+	NoPinCapability:      "No PIN capability",
+	PinPadInoperative:    "PIN pad inoperative",
+}
+
+//String returns the name of the PIN capability, or its code if it has no name.
+func (p PinCapability) String() string {
+	return lookupName(pinCapabilityLookup[p], string(p))
+}
+
+//PosEntryMode is DE22 of the 1987 version: the PAN entry mode followed by the PIN capability.
+type PosEntryMode struct {
+	PanEntryMode  PanEntryMode
+	PinCapability PinCapability
+}
+
+//ParsePosEntryMode reads a three digit POS entry mode.
+func ParsePosEntryMode(value string) (PosEntryMode, error) {
+	if len(value) != 3 || !util.IsDigits(value) {
+		return PosEntryMode{}, errors.New(fmt.Sprint("Invalid POS entry mode ", value))
+	}
+	return PosEntryMode{PanEntryMode(value[0:2]), PinCapability(value[2:3])}, nil
+}
+
+//Format returns the field value of the POS entry mode. The PAN entry mode must be two digits and the PIN capability one.
+func (p PosEntryMode) Format() (string, error) {
+	value := string(p.PanEntryMode) + string(p.PinCapability)
+	if len(p.PanEntryMode) != 2 || len(p.PinCapability) != 1 || !util.IsDigits(value) {
+		return "", errors.New(fmt.Sprint("Invalid POS entry mode ", p.PanEntryMode, "/", p.PinCapability))
+	}
+	return value, nil
+}
+
+func (p PosEntryMode) String() string {
+	return fmt.Sprint(p.PanEntryMode, ", ", p.PinCapability)
+}
+
+//GetPosEntryMode returns the POS entry mode, DE22.
+func (m *BitmapMessage) GetPosEntryMode() (PosEntryMode, bool, error) {
+	value, ok := m.GetString(22)
+	if !ok {
+		return PosEntryMode{}, false, nil
+	}
+	p, err := ParsePosEntryMode(value)
+	return p, true, err
+}
+
+//SetPosEntryMode sets the POS entry mode, DE22.
+func (m *BitmapMessage) SetPosEntryMode(p PosEntryMode) error {
+	value, err := p.Format()
+	if err != nil {
+		return err
+	}
+	m.SetString(22, value)
+	return nil
+}
+
+//NewPosEntryModeField creates a POS entry mode field, shown by name in String() output.
+func NewPosEntryModeField(bitNumber int, name string) Field {
+	return &describedField{NewFixedField(bitNumber, name, 3, Numeric).(*BitmapMessageField), func(value string) string {
+		p, err := ParsePosEntryMode(value)
+		if err != nil {
+			return ""
+		}
+		return p.String()
+	}}
+}
+
+//PosConditionCode is DE25: the conditions at the point of service.
+type PosConditionCode string
+
+const (
+	PosConditionNormal                        PosConditionCode = "00"
+	PosConditionCustomerNotPresent            PosConditionCode = "01"
+	PosConditionUnattendedTerminal            PosConditionCode = "02"
+	PosConditionMerchantSuspicious            PosConditionCode = "03"
+	PosConditionCustomerPresentCardNotPresent PosConditionCode = "05"
+	PosConditionPreAuthorization              PosConditionCode = "06"
+	PosConditionMailOrTelephoneOrder          PosConditionCode = "08"
+	PosConditionAccountVerification           PosConditionCode = "51"
+	PosConditionECommerce                     PosConditionCode = "59"
+)
+
+var posConditionCodeLookup = map[PosConditionCode]string{
+	PosConditionNormal:                        "Normal",
+	PosConditionCustomerNotPresent:            "Customer not present",
+	PosConditionUnattendedTerminal:            "Unattended terminal",
+	PosConditionMerchantSuspicious:            "Merchant suspicious",
+	PosConditionCustomerPresentCardNotPresent: "Customer present, card not present",
+	PosConditionPreAuthorization:              "Pre-authorization",
+	PosConditionMailOrTelephoneOrder:          "Mail or telephone order",
+	PosConditionAccountVerification:           "Account verification",
+	PosConditionECommerce:                     "E-commerce",
+}
+
+//String returns the name of the condition code, or its code if it has no name.
+func (p PosConditionCode) String() string {
+	return lookupName(posConditionCodeLookup[p], string(p))
+}
+
+//GetPosConditionCode returns the POS condition code, DE25.
+func (m *BitmapMessage) GetPosConditionCode() (PosConditionCode, bool) {
+	value, ok := m.GetString(25)
+	return PosConditionCode(value), ok
+}
+
+//SetPosConditionCode sets the POS condition code, DE25.
+func (m *BitmapMessage) SetPosConditionCode(p PosConditionCode) {
+	m.SetString(25, string(p))
+}
+
+//NewPosConditionCodeField creates a POS condition code field, shown by name in String() output.
+func NewPosConditionCodeField(bitNumber int, name string) Field {
+	return &describedField{NewFixedField(bitNumber, name, 2, Numeric).(*BitmapMessageField), func(value string) string {
+		if _, ok := posConditionCodeLookup[PosConditionCode(value)]; !ok {
+			return ""
+		}
+		return PosConditionCode(value).String()
+	}}
+}
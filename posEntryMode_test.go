@@ -0,0 +1,25 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func TestPosEntryModeFormat(t *testing.T) {
+	p, err := ParsePosEntryMode("051")
+	if err != nil || p != (PosEntryMode{PanEntryChip, PinCapable}) {
+		t.Fatalf("051 parsed as %v %v", p, err)
+	}
+	if value, err := p.Format(); err != nil || value != "051" {
+		t.Errorf("formatted as %s %v, want 051", value, err)
+	}
+	for _, invalid := range []PosEntryMode{
+		{"5", "01"},
+		{PanEntryChip, ""},
+		{PanEntryChip, "12"},
+		{"0X", PinCapable},
+	} {
+		if value, err := invalid.Format(); err == nil {
+			t.Errorf("formatted invalid POS entry mode %#v as %s", invalid, value)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package go8583
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+type positionalField struct {
+	*BitmapMessageField
+	*BitmapMessageTemplate
+	order []int
+}
+
+//NewPositionalField creates a field made up of subfields packed one after another in the order given, such as DE90 original data elements.
+func NewPositionalField(fieldNumber int, name string, size int, fieldPackerUnpacker PackerUnpacker, fields []Field) Field {
+	field := &positionalField{&BitmapMessageField{fieldNumber, name, AlphaNumericSpecial, packerLength(fieldPackerUnpacker), size, fieldPackerUnpacker, nil, nil},
+		new(BitmapMessageTemplate),
+		nil,
+	}
+	field.Fields = CreateFields(fields...)
+	for _, f := range fields {
+		field.order = append(field.order, f.GetFieldNumber())
+	}
+	return field
+}
+
+//NewFixedPositionalField creates a positional field whose size is the total of its subfield sizes.
+func NewFixedPositionalField(fieldNumber int, name string, fields []Field) Field {
+	size := 0
+	for _, f := range fields {
+		size += f.GetSize()
+	}
+	return NewPositionalField(fieldNumber, name, size, NewFixedFieldPackerUnpacker(size), fields)
+}
+
+func (f *positionalField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value, err = f.unpackSubFields(fieldData)
+	return newOffset, value, err
+}
+
+func (f *positionalField) PackField(value FieldValue) (data []byte, err error) {
+	subFieldData, err := f.packSubFields(value)
+	if err != nil {
+		return nil, err
+	}
+	return f.Pack(subFieldData)
+}
+
+//unpackSubFields reads subfields in order. Trailing subfields may be absent.
+func (f *positionalField) unpackSubFields(data []byte) (value FieldValue, err error) {
+	values := make(map[int]FieldValue)
+	offset := 0
+	for _, subFieldNr := range f.order {
+		if offset >= len(data) {
+			break
+		}
+		var subValue FieldValue
+		offset, subValue, err = f.Fields[subFieldNr].UnpackField(offset, data)
+		if err != nil {
+			return value, errors.New(fmt.Sprint("Error unpacking subfield ", subFieldNr, ": ", err))
+		}
+		values[subFieldNr] = subValue
+	}
+	if offset != len(data) {
+		return value, errors.New(fmt.Sprint("Unexpected data after last subfield of field ", f.FieldNumber))
+	}
+	return FieldValue{FieldValues: values}, nil
+}
+
+//packSubFields writes every subfield in order, padding subfields which are not set. The subfields must fit the size.
+func (f *positionalField) packSubFields(value FieldValue) (data []byte, err error) {
+	values := value.FieldValues
+	buf := new(bytes.Buffer)
+	for _, subFieldNr := range f.order {
+		subFieldData, err := f.Fields[subFieldNr].PackField(values[subFieldNr])
+		if err != nil {
+			return nil, errors.New(fmt.Sprint("Error packing subfield ", subFieldNr, ": ", err))
+		}
+		buf.Write(subFieldData)
+	}
+	if buf.Len() > f.Size {
+		return nil, errors.New(fmt.Sprint("Field ", f.FieldNumber, " length ", buf.Len(), " exceeds ", f.Size))
+	}
+	return buf.Bytes(), nil
+}
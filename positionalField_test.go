@@ -0,0 +1,117 @@
+package go8583
+
+import (
+	"strings"
+	"testing"
+)
+
+func positionalTestTemplate() *BitmapMessageTemplate {
+	return (&BitmapMessageTemplate{Fields: CreateFields(
+		NewFixedPositionalField(90, "originalDataElements", []Field{
+			NewFixedField(1, "mti", 4, Numeric),
+			NewFixedField(2, "stan", 6, Numeric),
+			NewFixedField(3, "dateTime", 10, Numeric),
+		}),
+		NewPositionalField(62, "privateData", 30, NewVariableFieldPackerUnpacker(3), []Field{
+			NewFixedField(1, "code", 2, AlphaNumeric),
+			NewLlVarField(2, "text", 20, AlphaNumericSpecial),
+		}),
+	)}).Freeze()
+}
+
+func TestPositionalFieldRoundTrip(t *testing.T) {
+	tmpl := positionalTestTemplate()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0420)
+	msg.SetSubField(90, 1, "0200")
+	msg.SetSubField(90, 2, "1234")
+	msg.SetSubField(90, 3, "1019123045")
+	msg.SetSubField(62, 1, "AB")
+	msg.SetSubField(62, 2, "hello")
+	packed, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(packed), "009AB05hello") || !strings.Contains(string(packed), "02000012341019123045") {
+		t.Errorf("packed %q", packed)
+	}
+	unpacked := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	unpacked.Init()
+	if err = BitmapUnpack(packed, tmpl, unpacked); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{"90.1": "0200", "90.2": "001234", "90.3": "1019123045", "62.1": "AB", "62.2": "hello"} {
+		if got, ok := unpacked.Get(path); !ok || got != want {
+			t.Errorf("%s is %q %v, want %q", path, got, ok, want)
+		}
+	}
+	repacked, err := unpacked.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(repacked) != string(packed) {
+		t.Errorf("repacked %q, want %q", repacked, packed)
+	}
+}
+
+func TestPositionalFieldInvalid(t *testing.T) {
+	tmpl := positionalTestTemplate()
+	field := tmpl.Fields[90].(*positionalField)
+	value, err := field.unpackSubFields([]byte("0200"))
+	if err != nil || len(value.FieldValues) != 1 {
+		t.Errorf("trailing subfields absent unpacked as %v %v", value.FieldValues, err)
+	}
+	for _, data := range []string{"0200001", "020000123410191230451"} {
+		if _, err := field.unpackSubFields([]byte(data)); err == nil {
+			t.Errorf("%q unpacked without error", data)
+		}
+	}
+	if _, _, err := field.UnpackField(0, []byte("0200000123")); err == nil {
+		t.Error("unpacked a short fixed positional field")
+	}
+	if _, err := field.PackField(FieldValue{FieldValues: map[int]FieldValue{2: {Value: "1234567"}}}); err == nil {
+		t.Error("packed an overlong subfield")
+	}
+	private := tmpl.Fields[62].(*positionalField)
+	if _, err := private.PackField(FieldValue{FieldValues: map[int]FieldValue{2: {Value: strings.Repeat("x", 27)}}}); err == nil {
+		t.Error("packed subfields exceeding the field size")
+	}
+}
+
+func TestPositionalFieldLength(t *testing.T) {
+	tests := []struct {
+		packer PackerUnpacker
+		length variableFieldLength
+	}{
+		{NewFixedFieldPackerUnpacker(10), Fixed},
+		{NewVariableFieldPackerUnpacker(2), LlVar},
+		{NewVariableFieldPackerUnpacker(3), LllVar},
+		{NewVariableFieldPackerUnpacker(4), LlllVar},
+	}
+	for _, test := range tests {
+		field := NewPositionalField(62, "privateData", 10, test.packer, []Field{NewFixedField(1, "code", 10, Numeric)})
+		if length := field.GetLength(); length != test.length {
+			t.Errorf("positional field length is %s, want %s", length, test.length)
+		}
+	}
+}
+
+func TestPositionalFieldString(t *testing.T) {
+	msg := &BitmapMessage{BitmapMessageTemplate: positionalTestTemplate()}
+	msg.Init()
+	msg.SetMsgType(0x0420)
+	msg.SetSubField(90, 1, "0200")
+	msg.SetSubField(90, 2, "001234")
+	msg.SetSubField(62, 2, "hello")
+	s := msg.String()
+	for _, want := range []string{
+		"[Fixed   n       4 004] 90.001  [0200]",
+		"[Fixed   n       6 006] 90.002  [001234]",
+		"[LlVar   ans    20 005] 62.002  [hello]",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String() has no %q:\n%s", want, s)
+		}
+	}
+}
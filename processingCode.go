@@ -0,0 +1,145 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583/util"
+)
+
+//TransactionType is the first two digits of the processing code, DE3.
+type TransactionType string
+
+const (
+	Purchase             TransactionType = "00"
+	Cash                 TransactionType = "01"
+	DebitAdjustment      TransactionType = "02"
+	PurchaseWithCashback TransactionType = "09"
+	QuasiCash            TransactionType = "11"
+	Refund               TransactionType = "20"
+	Deposit              TransactionType = "21"
+	CreditAdjustment     TransactionType = "22"
+	BalanceInquiry       TransactionType = "30"
+	Transfer             TransactionType = "40"
+	Payment              TransactionType = "50"
+)
+
+var transactionTypeLookup = map[TransactionType]string{
+	Purchase:             "Purchase",
+	Cash:                 "Cash",
+	DebitAdjustment:      "Debit adjustment",
+	PurchaseWithCashback: "Purchase with cashback",
+	QuasiCash:            "Quasi cash",
+	Refund:               "Refund",
+	Deposit:              "Deposit",
+	CreditAdjustment:     "Credit adjustment",
+	BalanceInquiry:       "Balance inquiry",
+	Transfer:             "Transfer",
+	Payment:              "Payment",
+}
+
+//String returns the name of the transaction type, or its code if it has no name.
+func (t TransactionType) String() string {
+	return lookupName(transactionTypeLookup[t], string(t))
+}
+
+//AccountType is the from or to account of the processing code.
+type AccountType string
+
+const (
+	DefaultAccount   AccountType = "00"
+	SavingsAccount   AccountType = "10"
+	ChequeAccount    AccountType = "20"
+	CreditAccount    AccountType = "30"
+	UniversalAccount AccountType = "40"
+)
+
+var accountTypeLookup = map[AccountType]string{
+	DefaultAccount:   "Default",
+	SavingsAccount:   "Savings",
+	ChequeAccount:    "Cheque",
+	CreditAccount:    "Credit",
+	UniversalAccount: "Universal",
+}
+
+//String returns the name of the account type, or its code if it has no name.
+func (a AccountType) String() string {
+	return lookupName(accountTypeLookup[a], string(a))
+}
+
+//ProcessingCode is DE3: the transaction type followed by the from and to account types.
+type ProcessingCode struct {
+	TransactionType TransactionType
+	From            AccountType
+	To              AccountType
+}
+
+//ParseProcessingCode reads a six digit processing code.
+func ParseProcessingCode(value string) (ProcessingCode, error) {
+	if len(value) != 6 || !util.IsDigits(value) {
+		return ProcessingCode{}, errors.New(fmt.Sprint("Invalid processing code ", value))
+	}
+	return ProcessingCode{TransactionType(value[0:2]), AccountType(value[2:4]), AccountType(value[4:6])}, nil
+}
+
+//Format returns the field value of the processing code. Each part must be two digits.
+func (p ProcessingCode) Format() (string, error) {
+	value := string(p.TransactionType) + string(p.From) + string(p.To)
+	if len(p.TransactionType) != 2 || len(p.From) != 2 || len(p.To) != 2 || !util.IsDigits(value) {
+		return "", errors.New(fmt.Sprint("Invalid processing code ", p.TransactionType, "/", p.From, "/", p.To))
+	}
+	return value, nil
+}
+
+func (p ProcessingCode) String() string {
+	return fmt.Sprint(p.TransactionType, " from ", p.From, " to ", p.To)
+}
+
+//GetProcessingCode returns the processing code, DE3.
+func (m *BitmapMessage) GetProcessingCode() (ProcessingCode, bool, error) {
+	value, ok := m.GetString(3)
+	if !ok {
+		return ProcessingCode{}, false, nil
+	}
+	p, err := ParseProcessingCode(value)
+	return p, true, err
+}
+
+//SetProcessingCode sets the processing code, DE3.
+func (m *BitmapMessage) SetProcessingCode(p ProcessingCode) error {
+	value, err := p.Format()
+	if err != nil {
+		return err
+	}
+	m.SetString(3, value)
+	return nil
+}
+
+//describedField is a plain field whose value is annotated in String() output, e.g. with the names of its codes.
+type describedField struct {
+	*BitmapMessageField
+	description func(value string) string
+}
+
+func (f *describedField) describe(value FieldValue) string {
+	return f.description(value.Value)
+}
+
+//NewProcessingCodeField creates a processing code field, shown by name in String() output.
+func NewProcessingCodeField(bitNumber int, name string) Field {
+	return &describedField{NewFixedField(bitNumber, name, 6, Numeric).(*BitmapMessageField), func(value string) string {
+		p, err := ParseProcessingCode(value)
+		if err != nil {
+			return ""
+		}
+		return p.String()
+	}}
+}
+
+//lookupName returns name, or code if the code has no name.
+func lookupName(name string, code string) string {
+	if name == "" {
+		return code
+	}
+	return name
+}
@@ -0,0 +1,30 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func TestProcessingCodeFormat(t *testing.T) {
+	p, err := ParseProcessingCode("092000")
+	if err != nil || p != (ProcessingCode{PurchaseWithCashback, ChequeAccount, DefaultAccount}) {
+		t.Fatalf("092000 parsed as %v %v", p, err)
+	}
+	if value, err := p.Format(); err != nil || value != "092000" {
+		t.Errorf("formatted as %s %v, want 092000", value, err)
+	}
+	for _, invalid := range []ProcessingCode{
+		{Purchase, "1", "100"},
+		{"", ChequeAccount, DefaultAccount},
+		{"0A", ChequeAccount, DefaultAccount},
+		{Purchase, ChequeAccount, "000"},
+	} {
+		if value, err := invalid.Format(); err == nil {
+			t.Errorf("formatted invalid processing code %#v as %s", invalid, value)
+		}
+	}
+	for _, value := range []string{"00200", "0020000", "00A000"} {
+		if _, err := ParseProcessingCode(value); err == nil {
+			t.Errorf("parsed invalid processing code %s", value)
+		}
+	}
+}
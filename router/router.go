@@ -0,0 +1,210 @@
+//Package router selects the destination of a message from a longest prefix table of PAN (BIN), DE32, DE100 and MTI
+//routes, optionally rewriting the message into the destination's template. The table can be reloaded while routing.
+package router
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/doswell/go8583"
+)
+
+//Endpoint sends messages to a destination, such as a client connection to a processor.
+type Endpoint interface {
+	Send(msg *go8583.BitmapMessage) error
+}
+
+//Destination is a named endpoint a route leads to.
+type Destination struct {
+	Name     string
+	Endpoint Endpoint
+	//Template is the destination's message layout. When set, messages are rewritten into it, dropping fields it does not define.
+	Template *go8583.BitmapMessageTemplate
+}
+
+//DefaultPrecedence tries the explicit receiving institution first, then the card prefix, the acquirer and the MTI.
+var DefaultPrecedence = []Key{ReceivingInstitution, Pan, AcquiringInstitution, MessageType}
+
+//Router routes messages using a table which can be replaced or reloaded at any time. It is safe for concurrent use.
+type Router struct {
+	//Precedence is the order keys are tried in. The first key with a matching route decides the destination.
+	Precedence []Key
+
+	lock         sync.RWMutex
+	table        *Table
+	path         string
+	modTime      time.Time
+	destinations map[string]*Destination
+}
+
+//NewRouter creates a router with an empty table and the default precedence.
+func NewRouter() *Router {
+	return &Router{
+		Precedence:   DefaultPrecedence,
+		table:        NewTable(),
+		destinations: make(map[string]*Destination),
+	}
+}
+
+//AddDestination registers a destination that routes may name.
+func (r *Router) AddDestination(destination *Destination) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.destinations[destination.Name] = destination
+}
+
+//SetTable replaces the routing table.
+func (r *Router) SetTable(table *Table) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.table = table
+}
+
+//LoadFile loads the routing table from a file, which Reload and Watch read again.
+func (r *Router) LoadFile(path string) error {
+	r.lock.Lock()
+	r.path = path
+	r.lock.Unlock()
+	return r.Reload()
+}
+
+//Reload reads the table file again. The current table is kept if the file cannot be read or parsed.
+func (r *Router) Reload() error {
+	r.lock.RLock()
+	path := r.path
+	r.lock.RUnlock()
+	if path == "" {
+		return errors.New("No table file loaded")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	table, err := LoadTable(path)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.table = table
+	r.modTime = info.ModTime()
+	return nil
+}
+
+//Watch reloads the table file whenever its modification time changes, checking every interval until stop is closed.
+//Reload errors are passed to onError, which may be nil.
+func (r *Router) Watch(interval time.Duration, stop <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var failedModTime time.Time //A file which failed to load is not retried until it changes again.
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		r.lock.RLock()
+		path, modTime := r.path, r.modTime
+		r.lock.RUnlock()
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil && (info.ModTime().Equal(modTime) || info.ModTime().Equal(failedModTime)) {
+			continue
+		}
+		if err == nil {
+			if err = r.Reload(); err != nil {
+				failedModTime = info.ModTime()
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+//Select returns the destination of the message.
+func (r *Router) Select(msg go8583.Message) (*Destination, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	for _, key := range r.Precedence {
+		value, isSet := keyValue(msg, key)
+		if !isSet {
+			continue
+		}
+		if name, ok := r.table.Lookup(key, value); ok {
+			return r.destination(name)
+		}
+	}
+	if r.table.defaultDest != "" {
+		return r.destination(r.table.defaultDest)
+	}
+	return nil, errors.New("No route for message")
+}
+
+func (r *Router) destination(name string) (*Destination, error) {
+	destination, ok := r.destinations[name]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("Unknown destination ", name))
+	}
+	return destination, nil
+}
+
+func keyValue(msg go8583.Message, key Key) (string, bool) {
+	switch key {
+	case Pan:
+		if pan, isSet := msg.GetField(2); isSet {
+			return pan, true
+		}
+		return msg.GetSubField(35, go8583.Track2Pan)
+	case AcquiringInstitution:
+		return msg.GetField(32)
+	case ReceivingInstitution:
+		return msg.GetField(100)
+	case MessageType:
+		return msg.GetMsgTypeString(), true
+	}
+	return "", false
+}
+
+//Route selects the destination of the message and returns the message rewritten into the destination's template.
+//The message is returned unchanged if the destination has no template.
+func (r *Router) Route(msg *go8583.BitmapMessage) (*Destination, *go8583.BitmapMessage, error) {
+	destination, err := r.Select(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if destination.Template == nil {
+		return destination, msg, nil
+	}
+	return destination, Rewrite(msg, destination.Template), nil
+}
+
+//Send routes the message and sends it to the destination's endpoint.
+func (r *Router) Send(msg *go8583.BitmapMessage) error {
+	destination, routed, err := r.Route(msg)
+	if err != nil {
+		return err
+	}
+	if destination.Endpoint == nil {
+		return errors.New(fmt.Sprint("Destination ", destination.Name, " has no endpoint"))
+	}
+	return destination.Endpoint.Send(routed)
+}
+
+//Rewrite copies the message into a new message of the template, keeping only the fields the template defines.
+func Rewrite(msg *go8583.BitmapMessage, tmpl *go8583.BitmapMessageTemplate) *go8583.BitmapMessage {
+	rewritten := &go8583.BitmapMessage{BitmapMessageTemplate: tmpl}
+	rewritten.Init()
+	rewritten.SetMsgType(msg.GetMsgType())
+	for fieldNr := range msg.FieldValues {
+		if _, err := tmpl.GetFieldDef(fieldNr); err == nil {
+			rewritten.CopyFields(msg, fieldNr)
+		}
+	}
+	return rewritten
+}
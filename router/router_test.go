@@ -0,0 +1,339 @@
+package router
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doswell/go8583"
+)
+
+const testTable = `# Test routes
+pan    4        visa
+pan    412345   issuerA
+pan    4123456  issuerB
+de32   100200   acquirerHost
+de100  999      switchB
+mti    08       netmgmt
+default         stip
+`
+
+func TestTableLookup(t *testing.T) {
+	table, err := ParseTable(strings.NewReader(testTable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key         Key
+		value       string
+		destination string
+		ok          bool
+	}{
+		{Pan, "4111111111111111", "visa", true},
+		{Pan, "4123451111111111", "issuerA", true},
+		{Pan, "4123456111111111", "issuerB", true},
+		{Pan, "412345", "issuerA", true}, //Value no longer than the longest prefix
+		{Pan, "41", "visa", true},
+		{Pan, "5555555555554444", "", false},
+		{Pan, "", "", false},
+		{AcquiringInstitution, "10020012", "acquirerHost", true},
+		{AcquiringInstitution, "100201", "", false},
+		{ReceivingInstitution, "999", "switchB", true},
+		{MessageType, "0800", "netmgmt", true},
+		{MessageType, "0810", "netmgmt", true},
+		{MessageType, "0200", "", false},
+	}
+	for _, test := range tests {
+		destination, ok := table.Lookup(test.key, test.value)
+		if destination != test.destination || ok != test.ok {
+			t.Errorf("Lookup(%s, %q) is %q %v, want %q %v", test.key, test.value, destination, ok, test.destination, test.ok)
+		}
+	}
+	if table.defaultDest != "stip" {
+		t.Errorf("default is %q, want stip", table.defaultDest)
+	}
+}
+
+func TestParseTableInvalid(t *testing.T) {
+	for _, line := range []string{
+		"pan 4",
+		"pan 4 visa extra",
+		"track2 4 visa",
+		"default",
+		"default stip extra",
+	} {
+		if _, err := ParseTable(strings.NewReader("pan 5 mastercard\n" + line + "\n")); err == nil {
+			t.Errorf("parsed invalid route %q", line)
+		} else if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("error %q does not give the line number", err)
+		}
+	}
+	if table, err := ParseTable(strings.NewReader("\n   \n# comment\n")); err != nil || len(table.prefixes) != 0 {
+		t.Errorf("blank and comment lines parsed as %v %v", table, err)
+	}
+}
+
+type recordingEndpoint struct {
+	sent []*go8583.BitmapMessage
+}
+
+func (e *recordingEndpoint) Send(msg *go8583.BitmapMessage) error {
+	e.sent = append(e.sent, msg)
+	return nil
+}
+
+func routerTestTemplate() *go8583.BitmapMessageTemplate {
+	return (&go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewLlVarField(2, "pan", 19, go8583.Numeric),
+		go8583.NewFixedField(3, "processingCode", 6, go8583.Numeric),
+		go8583.NewLlVarField(32, "acquiringInstId", 11, go8583.Numeric),
+		go8583.NewTrack2Field(35, "track2", go8583.NewVariableFieldPackerUnpacker(2)),
+		go8583.NewTlvField(48, "additionalData", 999, go8583.NewVariableFieldPackerUnpacker(3), go8583.AsciiTlv2x2, nil),
+		go8583.NewLlVarField(100, "receivingInstId", 11, go8583.Numeric),
+	)}).Freeze()
+}
+
+func newTestRouter(t *testing.T, table string) *Router {
+	t.Helper()
+	r := NewRouter()
+	parsed, err := ParseTable(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetTable(parsed)
+	for _, name := range []string{"visa", "issuerA", "issuerB", "acquirerHost", "switchB", "netmgmt", "stip"} {
+		r.AddDestination(&Destination{Name: name, Endpoint: &recordingEndpoint{}})
+	}
+	return r
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		msgType     int
+		fields      map[int]string
+		precedence  []Key
+		destination string
+	}{
+		{"receiving institution first", 0x0200, map[int]string{2: "4123451111111111", 32: "100200", 100: "999"}, nil, "switchB"},
+		{"PAN before acquirer", 0x0200, map[int]string{2: "4123451111111111", 32: "100200"}, nil, "issuerA"},
+		{"longest PAN prefix", 0x0200, map[int]string{2: "4123456111111111"}, nil, "issuerB"},
+		{"acquirer when the PAN has no route", 0x0200, map[int]string{2: "5555555555554444", 32: "10020099"}, nil, "acquirerHost"},
+		{"unmatched receiving institution", 0x0200, map[int]string{2: "4111111111111111", 100: "123"}, nil, "visa"},
+		{"message type", 0x0800, nil, nil, "netmgmt"},
+		{"default route", 0x0200, map[int]string{2: "5555555555554444"}, nil, "stip"},
+		{"custom precedence", 0x0200, map[int]string{2: "4123451111111111", 32: "100200", 100: "999"}, []Key{AcquiringInstitution, Pan}, "acquirerHost"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestRouter(t, testTable)
+			if test.precedence != nil {
+				r.Precedence = test.precedence
+			}
+			msg := &go8583.BitmapMessage{BitmapMessageTemplate: routerTestTemplate()}
+			msg.Init()
+			msg.SetMsgType(test.msgType)
+			for fieldNr, value := range test.fields {
+				msg.SetString(fieldNr, value)
+			}
+			destination, err := r.Select(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if destination.Name != test.destination {
+				t.Errorf("routed to %s, want %s", destination.Name, test.destination)
+			}
+		})
+	}
+}
+
+func TestSelectTrack2Pan(t *testing.T) {
+	r := newTestRouter(t, testTable)
+	msg := &go8583.BitmapMessage{BitmapMessageTemplate: routerTestTemplate()}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetSubField(35, go8583.Track2Pan, "4123456111111111")
+	if destination, err := r.Select(msg); err != nil || destination.Name != "issuerB" {
+		t.Errorf("routed to %v %v, want issuerB", destination, err)
+	}
+}
+
+func TestSelectNoRoute(t *testing.T) {
+	r := newTestRouter(t, "pan 4 visa\npan 5 unknown\n")
+	msg := &go8583.BitmapMessage{BitmapMessageTemplate: routerTestTemplate()}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(2, "6011111111111117")
+	if destination, err := r.Select(msg); err == nil {
+		t.Errorf("routed to %s without a default route", destination.Name)
+	}
+	msg.SetString(2, "5555555555554444")
+	if _, err := r.Select(msg); err == nil || !strings.Contains(err.Error(), "Unknown destination") {
+		t.Errorf("routing to an unregistered destination returned %v", err)
+	}
+}
+
+func TestRewriteAndSend(t *testing.T) {
+	source := &go8583.BitmapMessage{BitmapMessageTemplate: routerTestTemplate()}
+	source.Init()
+	source.SetMsgType(0x0200)
+	source.SetString(2, "4123451111111111")
+	source.SetString(3, "000000")
+	source.SetString(32, "100200")
+	source.SetSubField(48, 1, "ABCD")
+
+	destTemplate := (&go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewLlVarField(2, "pan", 19, go8583.Numeric),
+		go8583.NewFixedField(3, "processingCode", 6, go8583.Numeric),
+		go8583.NewTlvField(48, "additionalData", 999, go8583.NewVariableFieldPackerUnpacker(3), go8583.AsciiTlv2x2, nil),
+	)}).Freeze()
+	rewritten := Rewrite(source, destTemplate)
+	if rewritten.BitmapMessageTemplate != destTemplate || rewritten.GetMsgType() != 0x0200 {
+		t.Errorf("rewritten message has template %p and type %x", rewritten.BitmapMessageTemplate, rewritten.GetMsgType())
+	}
+	if rewritten.IsFieldSet(32) {
+		t.Error("field 32 is not in the destination template but was copied")
+	}
+	if value, _ := rewritten.GetSubField(48, 1); value != "ABCD" {
+		t.Errorf("subfield 48.01 is %q, want ABCD", value)
+	}
+	rewritten.SetSubField(48, 1, "WXYZ")
+	if value, _ := source.GetSubField(48, 1); value != "ABCD" {
+		t.Errorf("editing the rewritten message changed the source to %q", value)
+	}
+	if _, err := rewritten.Pack(); err != nil {
+		t.Error(err)
+	}
+
+	r := newTestRouter(t, testTable)
+	endpoint := &recordingEndpoint{}
+	r.AddDestination(&Destination{Name: "issuerA", Endpoint: endpoint, Template: destTemplate})
+	if err := r.Send(source); err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoint.sent) != 1 || endpoint.sent[0].BitmapMessageTemplate != destTemplate || endpoint.sent[0].IsFieldSet(32) {
+		t.Errorf("sent %v, want the message rewritten for issuerA", endpoint.sent)
+	}
+	r.AddDestination(&Destination{Name: "issuerA"})
+	if err := r.Send(source); err == nil {
+		t.Error("sent to a destination without an endpoint")
+	}
+}
+
+func writeTable(t *testing.T, path, table string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(table), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func selectPan(t *testing.T, r *Router, pan string) string {
+	t.Helper()
+	msg := &go8583.BitmapMessage{BitmapMessageTemplate: routerTestTemplate()}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(2, pan)
+	destination, err := r.Select(msg)
+	if err != nil {
+		return ""
+	}
+	return destination.Name
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes")
+	r := newTestRouter(t, "")
+	if err := r.Reload(); err == nil {
+		t.Error("reloaded without a table file")
+	}
+	start := time.Now().Add(-time.Hour)
+	writeTable(t, path, "pan 4 visa\n", start)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if name := selectPan(t, r, "4123451111111111"); name != "visa" {
+		t.Fatalf("routed to %q, want visa", name)
+	}
+	writeTable(t, path, "pan 4 visa\npan 412345 issuerA\n", start.Add(time.Minute))
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if name := selectPan(t, r, "4123451111111111"); name != "issuerA" {
+		t.Errorf("after reloading routed to %q, want issuerA", name)
+	}
+	writeTable(t, path, "pan 4\n", start.Add(2*time.Minute))
+	if err := r.Reload(); err == nil {
+		t.Error("reloaded an invalid table")
+	}
+	if name := selectPan(t, r, "4123451111111111"); name != "issuerA" {
+		t.Errorf("after a failed reload routed to %q, want the previous table's issuerA", name)
+	}
+	if err := r.LoadFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes")
+	start := time.Now().Add(-time.Hour)
+	writeTable(t, path, "pan 4 visa\n", start)
+	r := newTestRouter(t, "")
+	if err := r.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	var errs []error
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Watch(time.Millisecond, stop, func(err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			errs = append(errs, err)
+		})
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	waitFor := func(condition func() bool, what string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	errorCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(errs)
+	}
+
+	writeTable(t, path, "pan 4 visa\npan 412345 issuerA\n", start.Add(time.Minute))
+	waitFor(func() bool { return selectPan(t, r, "4123451111111111") == "issuerA" }, "the changed table to load")
+
+	writeTable(t, path, "pan 4\n", start.Add(2*time.Minute))
+	waitFor(func() bool { return errorCount() > 0 }, "the invalid table to be reported")
+	time.Sleep(20 * time.Millisecond)
+	if n := errorCount(); n != 1 {
+		t.Errorf("invalid table reported %d times, want once until it changes", n)
+	}
+	if name := selectPan(t, r, "4123451111111111"); name != "issuerA" {
+		t.Errorf("after an invalid table routed to %q, want issuerA", name)
+	}
+
+	writeTable(t, path, "pan 4 issuerB\n", start.Add(3*time.Minute))
+	waitFor(func() bool { return selectPan(t, r, "4123451111111111") == "issuerB" }, "the fixed table to load")
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//Key is the message value a route matches on.
+type Key int
+
+const (
+	Pan                  Key = iota + 1 //DE2, or the PAN in the track 2 data of DE35
+	AcquiringInstitution                //DE32
+	ReceivingInstitution                //DE100
+	MessageType                         //The MTI, e.g. 08 for network management
+)
+
+var keyLookup = map[Key]string{
+	Pan:                  "pan",
+	AcquiringInstitution: "de32",
+	ReceivingInstitution: "de100",
+	MessageType:          "mti",
+}
+
+func (k Key) String() string {
+	return keyLookup[k]
+}
+
+func parseKey(s string) (Key, bool) {
+	for key, name := range keyLookup {
+		if name == s {
+			return key, true
+		}
+	}
+	return 0, false
+}
+
+//Table maps value prefixes to destination names. The longest matching prefix wins.
+type Table struct {
+	prefixes    map[Key]map[string]string
+	maxLength   map[Key]int
+	defaultDest string
+}
+
+//NewTable creates an empty table.
+func NewTable() *Table {
+	return &Table{prefixes: make(map[Key]map[string]string), maxLength: make(map[Key]int)}
+}
+
+//Add routes values of the key starting with prefix to the destination.
+func (t *Table) Add(key Key, prefix string, destination string) {
+	if t.prefixes[key] == nil {
+		t.prefixes[key] = make(map[string]string)
+	}
+	t.prefixes[key][prefix] = destination
+	if len(prefix) > t.maxLength[key] {
+		t.maxLength[key] = len(prefix)
+	}
+}
+
+//SetDefault sets the destination of messages no route matches.
+func (t *Table) SetDefault(destination string) {
+	t.defaultDest = destination
+}
+
+//Lookup returns the destination of the longest prefix of value for the key.
+func (t *Table) Lookup(key Key, value string) (destination string, ok bool) {
+	prefixes := t.prefixes[key]
+	if prefixes == nil {
+		return "", false
+	}
+	length := t.maxLength[key]
+	if length > len(value) {
+		length = len(value)
+	}
+	for ; length >= 0; length-- {
+		if destination, ok = prefixes[value[:length]]; ok {
+			return destination, true
+		}
+	}
+	return "", false
+}
+
+//ParseTable reads a table of one route per line, "key prefix destination", where key is pan, de32, de100 or mti.
+//A line "default destination" sets the default. Blank lines and lines starting with # are ignored.
+//
+//	pan    4        visa
+//	pan    412345   issuerA
+//	de32   100200   acquirerHost
+//	default         stip
+func ParseTable(r io.Reader) (*Table, error) {
+	t := NewTable()
+	scanner := bufio.NewScanner(r)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "default" && len(fields) == 2 {
+			t.SetDefault(fields[1])
+			continue
+		}
+		key, ok := parseKey(fields[0])
+		if !ok || len(fields) != 3 {
+			return nil, errors.New(fmt.Sprint("Invalid route on line ", lineNr, ": ", scanner.Text()))
+		}
+		t.Add(key, fields[1], fields[2])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+//LoadTable reads a table from a file, see ParseTable.
+func LoadTable(path string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseTable(file)
+}
@@ -0,0 +1,196 @@
+package go8583
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/doswell/go8583/card"
+	"github.com/doswell/go8583/util"
+)
+
+type presence int
+
+//Presence of a field within a message type, as in network specification tables.
+const (
+	Optional presence = iota
+	Mandatory
+	Conditional
+	NotAllowed
+)
+
+var presenceLookup = map[presence]string{
+	Optional:    "O",
+	Mandatory:   "M",
+	Conditional: "C",
+	NotAllowed:  "X",
+}
+
+func (p presence) String() string {
+	return presenceLookup[p]
+}
+
+//FieldRule defines the presence of a field in a message type.
+type FieldRule struct {
+	FieldNumber int
+	Presence    presence
+	//Condition decides whether a Conditional field is required. The field is optional when the condition is false.
+	Condition func(msg Message) bool
+}
+
+//MandatoryField requires the field to be present.
+func MandatoryField(fieldNr int) FieldRule {
+	return FieldRule{FieldNumber: fieldNr, Presence: Mandatory}
+}
+
+//OptionalField allows the field to be present.
+func OptionalField(fieldNr int) FieldRule {
+	return FieldRule{FieldNumber: fieldNr, Presence: Optional}
+}
+
+//ConditionalField requires the field to be present when condition is true.
+func ConditionalField(fieldNr int, condition func(msg Message) bool) FieldRule {
+	return FieldRule{FieldNumber: fieldNr, Presence: Conditional, Condition: condition}
+}
+
+//NotAllowedField requires the field to be absent.
+func NotAllowedField(fieldNr int) FieldRule {
+	return FieldRule{FieldNumber: fieldNr, Presence: NotAllowed}
+}
+
+//FieldIsSet is a condition which is true when the field is present.
+func FieldIsSet(fieldNr int) func(msg Message) bool {
+	return func(msg Message) bool {
+		_, isSet := msg.GetField(fieldNr)
+		return isSet
+	}
+}
+
+//FieldIsNotSet is a condition which is true when the field is absent.
+func FieldIsNotSet(fieldNr int) func(msg Message) bool {
+	isSet := FieldIsSet(fieldNr)
+	return func(msg Message) bool {
+		return !isSet(msg)
+	}
+}
+
+//FieldEquals is a condition which is true when the field is present with the value.
+func FieldEquals(fieldNr int, value string) func(msg Message) bool {
+	return func(msg Message) bool {
+		v, isSet := msg.GetField(fieldNr)
+		return isSet && v == value
+	}
+}
+
+//MessageValidator checks a message beyond field presence, returning the violations it finds.
+type MessageValidator func(msg Message) ValidationErrors
+
+//PanLuhnValidator checks the Luhn check digit of the PAN in DE2 and in the track 2 data of DE35, whether DE35 is a
+//track 2 field or a plain one.
+func PanLuhnValidator(msg Message) (errs ValidationErrors) {
+	if pan, isSet := msg.GetField(2); isSet {
+		if err := card.Validate(pan); err != nil {
+			errs = append(errs, ValidationError{msg.GetMsgType(), 2, err.Error()})
+		}
+	}
+	if pan, isSet := track2Pan(msg); isSet {
+		if err := card.Validate(pan); err != nil {
+			errs = append(errs, ValidationError{msg.GetMsgType(), 35, err.Error()})
+		}
+	}
+	return errs
+}
+
+//ValidationError is a single rule violated by a message.
+type ValidationError struct {
+	MsgType     int
+	FieldNumber int
+	Reason      string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprint(util.LeftPad2Len(fmt.Sprintf("%x", e.MsgType), "0", 4), " field ", e.FieldNumber, ": ", e.Reason)
+}
+
+//ValidationErrors holds every rule violated by a message.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	buf := new(bytes.Buffer)
+	for i, err := range e {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+//AddRules sets the presence rules of fields for a message type, e.g. 0x0200. Rules cannot be added once the template is frozen.
+func (t *BitmapMessageTemplate) AddRules(msgType int, rules ...FieldRule) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return errors.New("Cannot add rules to a frozen template")
+	}
+	if t.Rules == nil {
+		t.Rules = make(map[int][]FieldRule)
+	}
+	msgRules := append(t.Rules[msgType], rules...)
+	sort.SliceStable(msgRules, func(i, j int) bool { return msgRules[i].FieldNumber < msgRules[j].FieldNumber })
+	t.Rules[msgType] = msgRules
+	return nil
+}
+
+//AddValidator adds a check run by Validate for messages of every type, such as PanLuhnValidator. Validators cannot be
+//added once the template is frozen.
+func (t *BitmapMessageTemplate) AddValidator(validator MessageValidator) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return errors.New("Cannot add validators to a frozen template")
+	}
+	t.Validators = append(t.Validators, validator)
+	return nil
+}
+
+//Validate checks msg against the rules for its message type, then runs the template's validators. All violations are
+//returned together as ValidationErrors, or nil if the message is valid.
+func (t *BitmapMessageTemplate) Validate(msg Message) error {
+	if !t.IsFrozen() {
+		t.lock.RLock()
+		defer t.lock.RUnlock()
+	}
+	var errs ValidationErrors
+	msgType := msg.GetMsgType()
+	for _, rule := range t.Rules[msgType] {
+		_, isSet := msg.GetField(rule.FieldNumber)
+		switch rule.Presence {
+		case Mandatory:
+			if !isSet {
+				errs = append(errs, ValidationError{msgType, rule.FieldNumber, "mandatory field missing"})
+			}
+		case Conditional:
+			if !isSet && rule.Condition != nil && rule.Condition(msg) {
+				errs = append(errs, ValidationError{msgType, rule.FieldNumber, "conditional field missing"})
+			}
+		case NotAllowed:
+			if isSet {
+				errs = append(errs, ValidationError{msgType, rule.FieldNumber, "field not allowed"})
+			}
+		}
+	}
+	for _, validator := range t.Validators {
+		errs = append(errs, validator(msg)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//Validate checks the message against its template's rules, before packing or after unpacking.
+func (m *BitmapMessage) Validate() error {
+	return m.BitmapMessageTemplate.Validate(m)
+}
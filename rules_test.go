@@ -0,0 +1,182 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func rulesTestTemplate(t *testing.T) *BitmapMessageTemplate {
+	t.Helper()
+	tmpl := &BitmapMessageTemplate{Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewFixedField(4, "amountTransaction", 12, Numeric),
+		NewFixedField(14, "dateExpiration", 4, Numeric),
+		NewFixedField(35, "track2", 37, AlphaNumericSpecial),
+		NewFixedField(39, "responseCode", 2, AlphaNumeric),
+		NewFixedField(54, "additionalAmounts", 20, AlphaNumericSpecial),
+	)}
+	rules := map[int][]FieldRule{
+		0x0200: {
+			MandatoryField(3),
+			MandatoryField(4),
+			ConditionalField(2, FieldIsNotSet(35)),
+			ConditionalField(14, FieldIsSet(2)),
+			ConditionalField(54, FieldEquals(3, "310000")),
+			NotAllowedField(39),
+		},
+		0x0210: {MandatoryField(39), OptionalField(54)},
+	}
+	for msgType, msgRules := range rules {
+		if err := tmpl.AddRules(msgType, msgRules...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	amountLimit := func(msg Message) ValidationErrors {
+		if amount, _ := msg.GetField(4); amount > "000000100000" {
+			return ValidationErrors{{msg.GetMsgType(), 4, "amount over limit"}}
+		}
+		return nil
+	}
+	if err := tmpl.AddValidator(amountLimit); err != nil {
+		t.Fatal(err)
+	}
+	return tmpl.Freeze()
+}
+
+func TestValidate(t *testing.T) {
+	tmpl := rulesTestTemplate(t)
+	tests := []struct {
+		name    string
+		msgType int
+		fields  map[int]string
+		want    ValidationErrors
+	}{
+		{"valid with PAN", 0x0200, map[int]string{2: "4012345678909", 3: "000000", 4: "000000001000", 14: "2812"}, nil},
+		{"valid with track 2", 0x0200, map[int]string{3: "000000", 4: "000000001000", 35: "4012345678909=2812"}, nil},
+		{"mandatory missing", 0x0200, map[int]string{35: "4012345678909=2812"}, ValidationErrors{
+			{0x0200, 3, "mandatory field missing"},
+			{0x0200, 4, "mandatory field missing"},
+		}},
+		{"conditional on absent field", 0x0200, map[int]string{3: "000000", 4: "000000001000"}, ValidationErrors{
+			{0x0200, 2, "conditional field missing"},
+		}},
+		{"conditional on present field", 0x0200, map[int]string{2: "4012345678909", 3: "000000", 4: "000000001000"}, ValidationErrors{
+			{0x0200, 14, "conditional field missing"},
+		}},
+		{"conditional on value", 0x0200, map[int]string{3: "310000", 4: "000000000000", 35: "4012345678909=2812"}, ValidationErrors{
+			{0x0200, 54, "conditional field missing"},
+		}},
+		{"not allowed", 0x0200, map[int]string{3: "000000", 4: "000000001000", 35: "4012345678909=2812", 39: "00"}, ValidationErrors{
+			{0x0200, 39, "field not allowed"},
+		}},
+		{"rules per message type", 0x0210, map[int]string{39: "00", 54: "0002840C000000001000"}, nil},
+		{"response missing response code", 0x0210, map[int]string{3: "000000"}, ValidationErrors{
+			{0x0210, 39, "mandatory field missing"},
+		}},
+		{"no rules for message type", 0x0800, map[int]string{39: "00"}, nil},
+		{"validator after rules", 0x0200, map[int]string{3: "000000", 4: "000000200000", 39: "00", 35: "4012345678909=2812"}, ValidationErrors{
+			{0x0200, 39, "field not allowed"},
+			{0x0200, 4, "amount over limit"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+			msg.Init()
+			msg.SetMsgType(test.msgType)
+			for fieldNr, value := range test.fields {
+				msg.SetString(fieldNr, value)
+			}
+			err := msg.Validate()
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("got %v, want no error", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("got %v, want ValidationErrors", err)
+			}
+			if !reflect.DeepEqual(errs, test.want) {
+				t.Errorf("got %v, want %v", errs, test.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsMessage(t *testing.T) {
+	errs := ValidationErrors{{0x0200, 3, "mandatory field missing"}, {0x0200, 39, "field not allowed"}}
+	if got, want := errs.Error(), "0200 field 3: mandatory field missing; 0200 field 39: field not allowed"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRulesFrozen(t *testing.T) {
+	tmpl := rulesTestTemplate(t)
+	if err := tmpl.AddRules(0x0200, MandatoryField(2)); err == nil {
+		t.Error("added rules to a frozen template")
+	}
+	if err := tmpl.AddValidator(PanLuhnValidator); err == nil {
+		t.Error("added a validator to a frozen template")
+	}
+}
+
+func TestPanLuhnValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		track2    Field
+		pan       string
+		track2Pan string
+		fields    []int
+	}{
+		{"valid", NewTrack2Field(35, "track2", NewVariableFieldPackerUnpacker(2)), "4012345678909", "4111111111111111", nil},
+		{"invalid PAN", NewTrack2Field(35, "track2", NewVariableFieldPackerUnpacker(2)), "4012345678900", "4111111111111111", []int{2}},
+		{"invalid track 2", NewTrack2Field(35, "track2", NewVariableFieldPackerUnpacker(2)), "", "4111111111111112", []int{35}},
+		{"invalid plain track 2", NewLlVarField(35, "track2", 37, AlphaNumericSpecial), "", "4111111111111112", []int{35}},
+		{"valid plain track 2", NewLlVarField(35, "track2", 37, AlphaNumericSpecial), "", "4111111111111111", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl := &BitmapMessageTemplate{Fields: CreateFields(NewLlVarField(2, "pan", 19, Numeric), test.track2)}
+			if err := tmpl.AddValidator(PanLuhnValidator); err != nil {
+				t.Fatal(err)
+			}
+			msg := &BitmapMessage{BitmapMessageTemplate: tmpl.Freeze()}
+			msg.Init()
+			msg.SetMsgType(0x0200)
+			if test.pan != "" {
+				msg.SetString(2, test.pan)
+			}
+			track2 := test.track2Pan + "=28121010000000000"
+			if _, delimited := test.track2.(*delimitedField); delimited {
+				_, value, err := test.track2.UnpackField(0, []byte(fmt.Sprint(len(track2), track2)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				msg.SetField(35, value)
+			} else {
+				msg.SetString(35, track2)
+			}
+			if pan, _ := msg.GetPan(); test.pan == "" && pan != test.track2Pan {
+				t.Errorf("PAN from track 2 is %q, want %q", pan, test.track2Pan)
+			}
+			err := msg.Validate()
+			var fields []int
+			var errs ValidationErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					fields = append(fields, e.FieldNumber)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("invalid fields %v, want %v: %v", fields, test.fields, err)
+			}
+		})
+	}
+}
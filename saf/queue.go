@@ -0,0 +1,182 @@
+package saf
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/doswell/go8583"
+)
+
+//Sender sends a message and returns nil once the destination has acknowledged it, e.g. with a 0430.
+type Sender interface {
+	Send(msg *go8583.BitmapMessage) error
+}
+
+//DefaultBackoff waits 5 seconds after the first failure, doubling up to 5 minutes.
+func DefaultBackoff(attempts int) time.Duration {
+	delay := 5 * time.Second
+	for i := 1; i < attempts && delay < 5*time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > 5*time.Minute {
+		delay = 5 * time.Minute
+	}
+	return delay
+}
+
+//Queue sends stored messages in the order they were added, one at a time, so a reversal is never overtaken by a later
+//advice. A message which fails is retried after a backoff as a repeat, e.g. 0421, and later messages wait for it.
+type Queue struct {
+	Template *go8583.BitmapMessageTemplate //Used to unpack stored messages
+	Store    Store
+	Sender   Sender
+	//Backoff returns the delay before the next attempt after attempts failures. DefaultBackoff is used if nil.
+	Backoff func(attempts int) time.Duration
+	//MaxAttempts discards a message after that many failed attempts, passing it to OnDiscard. 0 retries forever.
+	MaxAttempts int
+	//OnDiscard is called with each message removed from the queue without being sent. For an entry which cannot be
+	//unpacked msg holds the fields unpacked before the error, and for a corrupt stored entry it is nil.
+	OnDiscard func(msg *go8583.BitmapMessage, err error)
+	//DeadLetter keeps entries which cannot be read or unpacked, if set, so they can be examined. They are removed from the queue
+	//either way, so later messages are not held up.
+	DeadLetter Store
+	//Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	lock sync.Mutex //Held while sending, so messages go one at a time
+	seq  uint32
+}
+
+//NewQueue creates a queue of messages of the template, kept in store and sent through sender.
+func NewQueue(tmpl *go8583.BitmapMessageTemplate, store Store, sender Sender) *Queue {
+	return &Queue{Template: tmpl, Store: store, Sender: sender}
+}
+
+func (q *Queue) now() time.Time {
+	if q.Now != nil {
+		return q.Now()
+	}
+	return time.Now()
+}
+
+//Add stores a message to be sent by the next call to Send or by Run.
+func (q *Queue) Add(msg *go8583.BitmapMessage) error {
+	data, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	seq := atomic.AddUint32(&q.seq, 1)
+	now := q.now()
+	entry := &Entry{
+		ID:          fmt.Sprintf("%020d-%010d", now.UnixNano(), seq),
+		Data:        data,
+		Created:     now,
+		NextAttempt: now,
+	}
+	return q.Store.Put(entry)
+}
+
+//Len returns the number of queued messages.
+func (q *Queue) Len() (int, error) {
+	entries, err := q.Store.List()
+	return len(entries), err
+}
+
+//Send sends queued messages in order until the queue is empty, a message fails or the next message is not yet due.
+func (q *Queue) Send() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	entries, err := q.Store.List()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.corrupt != nil {
+			if err = q.discard(entry, nil, entry.corrupt); err != nil {
+				return err
+			}
+			continue
+		}
+		if q.now().Before(entry.NextAttempt) {
+			return nil
+		}
+		msg := &go8583.BitmapMessage{BitmapMessageTemplate: q.Template}
+		msg.Init()
+		if err = go8583.BitmapUnpack(entry.Data, q.Template, msg); err != nil {
+			err = errors.New(fmt.Sprint("Cannot unpack SAF entry ", entry.ID, ": ", err))
+			if err = q.discard(entry, msg, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if entry.Attempts > 0 {
+			msg.SetMsgType(Repeat(msg.GetMsgType()))
+		}
+
+		sendErr := q.Sender.Send(msg)
+		if sendErr == nil {
+			if err = q.Store.Delete(entry.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		entry.Attempts++
+		entry.LastError = sendErr.Error()
+		if q.MaxAttempts > 0 && entry.Attempts >= q.MaxAttempts {
+			if err = q.Store.Delete(entry.ID); err != nil {
+				return err
+			}
+			if q.OnDiscard != nil {
+				q.OnDiscard(msg, sendErr)
+			}
+			continue
+		}
+		backoff := q.Backoff
+		if backoff == nil {
+			backoff = DefaultBackoff
+		}
+		entry.NextAttempt = q.now().Add(backoff(entry.Attempts))
+		if err = q.Store.Put(entry); err != nil {
+			return err
+		}
+		return sendErr
+	}
+	return nil
+}
+
+//discard moves an entry which cannot be read or unpacked to the dead letter store and removes it from the queue.
+func (q *Queue) discard(entry *Entry, msg *go8583.BitmapMessage, reason error) error {
+	entry.LastError = reason.Error()
+	if q.DeadLetter != nil {
+		if err := q.DeadLetter.Put(entry); err != nil {
+			return err
+		}
+	}
+	if err := q.Store.Delete(entry.ID); err != nil {
+		return err
+	}
+	if q.OnDiscard != nil {
+		q.OnDiscard(msg, reason)
+	}
+	return nil
+}
+
+//Run calls Send every interval until stop is closed. Send errors are passed to onError, which may be nil.
+func (q *Queue) Run(interval time.Duration, stop <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := q.Send(); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
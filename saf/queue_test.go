@@ -0,0 +1,141 @@
+package saf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/doswell/go8583"
+)
+
+type recordingSender struct {
+	sent []*go8583.BitmapMessage
+	err  error
+}
+
+func (s *recordingSender) Send(msg *go8583.BitmapMessage) error {
+	s.sent = append(s.sent, msg)
+	return s.err
+}
+
+func queueTemplate() *go8583.BitmapMessageTemplate {
+	return &go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
+	)}
+}
+
+func queueMessage(tmpl *go8583.BitmapMessageTemplate, stan string) *go8583.BitmapMessage {
+	msg := &go8583.BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0420)
+	msg.SetString(11, stan)
+	return msg
+}
+
+func TestSendSkipsUnpackableEntry(t *testing.T) {
+	tmpl := queueTemplate()
+	sender := new(recordingSender)
+	q := NewQueue(tmpl, NewMemoryStore(), sender)
+	q.DeadLetter = NewMemoryStore()
+	var discarded []error
+	q.OnDiscard = func(msg *go8583.BitmapMessage, err error) {
+		discarded = append(discarded, err)
+	}
+	now := time.Now()
+	q.Store.Put(&Entry{ID: "0", Data: []byte("0420 not a message"), Created: now, NextAttempt: now})
+	if err := q.Add(queueMessage(tmpl, "000001")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d messages, want the one after the unpackable entry", len(sender.sent))
+	}
+	if stan, _ := sender.sent[0].GetString(11); stan != "000001" {
+		t.Errorf("sent STAN %s, want 000001", stan)
+	}
+	if len(discarded) != 1 {
+		t.Errorf("discarded %d entries, want 1", len(discarded))
+	}
+	if n, _ := q.Len(); n != 0 {
+		t.Errorf("%d entries left in the queue", n)
+	}
+	dead, _ := q.DeadLetter.List()
+	if len(dead) != 1 || dead[0].ID != "0" || dead[0].LastError == "" {
+		t.Errorf("dead letters %+v, want entry 0 with its error", dead)
+	}
+}
+
+func TestSendRetriesInOrder(t *testing.T) {
+	tmpl := queueTemplate()
+	sender := &recordingSender{err: errors.New("no response")}
+	q := NewQueue(tmpl, NewMemoryStore(), sender)
+	now := time.Now()
+	q.Now = func() time.Time { return now }
+	q.Add(queueMessage(tmpl, "000001"))
+	q.Add(queueMessage(tmpl, "000002"))
+
+	if err := q.Send(); err == nil {
+		t.Fatal("send failure not returned")
+	}
+	if err := q.Send(); err != nil || len(sender.sent) != 1 {
+		t.Fatalf("sent %d messages before the backoff %v", len(sender.sent), err)
+	}
+	sender.err = nil
+	now = now.Add(DefaultBackoff(1))
+	if err := q.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(sender.sent))
+	}
+	if sender.sent[1].GetMsgType() != 0x0421 {
+		t.Errorf("retry sent as %04X, want the repeat 0421", sender.sent[1].GetMsgType())
+	}
+	if stan, _ := sender.sent[2].GetString(11); stan != "000002" || sender.sent[2].GetMsgType() != 0x0420 {
+		t.Errorf("sent %04X %s last, want 0420 000002", sender.sent[2].GetMsgType(), stan)
+	}
+}
+
+func TestSendSkipsCorruptEntry(t *testing.T) {
+	tmpl := queueTemplate()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := NewMemoryStore()
+	sender := new(recordingSender)
+	q := NewQueue(tmpl, store, sender)
+	q.DeadLetter = dead
+	var discarded []*go8583.BitmapMessage
+	q.OnDiscard = func(msg *go8583.BitmapMessage, err error) {
+		discarded = append(discarded, msg)
+	}
+	if err = os.WriteFile(filepath.Join(store.(*fileStore).dir, "0.json"), []byte(`{"id":"0","da`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = q.Add(queueMessage(tmpl, "000001")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = q.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d messages, want the one after the corrupt entry", len(sender.sent))
+	}
+	if len(discarded) != 1 || discarded[0] != nil {
+		t.Errorf("discarded %v, want the corrupt entry without a message", discarded)
+	}
+	if n, err := q.Len(); n != 0 || err != nil {
+		t.Errorf("%d entries left in the queue %v", n, err)
+	}
+	entries, _ := dead.List()
+	if len(entries) != 1 || entries[0].ID != "0" || string(entries[0].Data) != `{"id":"0","da` || entries[0].LastError == "" {
+		t.Errorf("dead letters %+v, want entry 0 with its contents and error", entries)
+	}
+}
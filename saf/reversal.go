@@ -0,0 +1,86 @@
+//Package saf builds reversals and keeps advices in a persistent store-and-forward (SAF) queue, retrying them with backoff
+//until the destination acknowledges them, across restarts.
+package saf
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/doswell/go8583"
+	"github.com/doswell/go8583/util"
+)
+
+//Subfields of DE90, original data elements.
+const (
+	OriginalMsgType = iota + 1
+	OriginalTraceNumber
+	OriginalTransmissionDateTime
+	OriginalAcquiringInstId
+	OriginalForwardingInstId
+)
+
+//DefaultEchoFields are copied from the original into a reversal. Track data, the PIN block and the MAC are not, nor is
+//DE7: the reversal gets its own transmission date and time and the original's is kept in DE90.
+var DefaultEchoFields = []int{2, 3, 4, 5, 6, 11, 12, 13, 14, 15, 18, 22, 23, 25, 32, 33, 37, 41, 42, 43, 49, 50, 51, 100}
+
+//ReversalMsgType returns the reversal advice message type for an original, keeping the version, e.g. 0200 gives 0420.
+func ReversalMsgType(msgType int) int {
+	return msgType&0xF000 | 0x0420
+}
+
+//Repeat returns the repeat of a message type, e.g. 0420 gives 0421. Repeats are returned unchanged.
+func Repeat(msgType int) int {
+	return msgType | 0x0001
+}
+
+//IsRepeat returns true for repeat message types such as 0421.
+func IsRepeat(msgType int) bool {
+	return msgType&0x0001 != 0
+}
+
+//NewReversal builds the reversal of original, copying the echo fields (DefaultEchoFields if none are given) and setting
+//DE90 from the original's MTI, DE11, DE7, DE32 and DE33.
+func NewReversal(original *go8583.BitmapMessage, echoFields ...int) (*go8583.BitmapMessage, error) {
+	if len(echoFields) == 0 {
+		echoFields = DefaultEchoFields
+	}
+	reversal := &go8583.BitmapMessage{BitmapMessageTemplate: original.BitmapMessageTemplate}
+	reversal.Init()
+	reversal.SetMsgType(ReversalMsgType(original.GetMsgType()))
+	for _, fieldNr := range echoFields {
+		if _, err := original.GetFieldDef(fieldNr); err == nil {
+			reversal.CopyFields(original, fieldNr)
+		}
+	}
+
+	field, err := original.GetFieldDef(90)
+	if err != nil {
+		return nil, errors.New("Template has no field 90 for the original data elements")
+	}
+	stan, _ := original.GetString(11)
+	transmissionDateTime, _ := original.GetString(7)
+	acquirer, _ := original.GetString(32)
+	forwarder, _ := original.GetString(33)
+	elements := []string{
+		original.GetMsgTypeString(),
+		util.LeftPad2Len(stan, "0", 6),
+		util.LeftPad2Len(transmissionDateTime, "0", 10),
+		util.LeftPad2Len(acquirer, "0", 11),
+		util.LeftPad2Len(forwarder, "0", 11),
+	}
+	if _, isComposite := field.(go8583.MessageTemplate); isComposite {
+		for i, element := range elements {
+			reversal.SetSubField(90, i+1, element)
+		}
+		return reversal, nil
+	}
+	var value string
+	for _, element := range elements {
+		value += element
+	}
+	if len(value) > field.GetSize() {
+		return nil, errors.New(fmt.Sprint("Field 90 is shorter than the ", len(value), " original data elements"))
+	}
+	reversal.SetString(90, value)
+	return reversal, nil
+}
@@ -0,0 +1,47 @@
+package saf
+
+import (
+	"testing"
+
+	"github.com/doswell/go8583"
+)
+
+func TestNewReversal(t *testing.T) {
+	tmpl := &go8583.BitmapMessageTemplate{Fields: go8583.CreateFields(
+		go8583.NewFixedField(2, "pan", 19, go8583.Numeric),
+		go8583.NewFixedField(7, "transmissionDateTime", 10, go8583.Numeric),
+		go8583.NewFixedField(11, "traceNumber", 6, go8583.Numeric),
+		go8583.NewFixedField(32, "acquiringInstId", 11, go8583.Numeric),
+		go8583.NewFixedField(52, "pinBlock", 16, go8583.AlphaNumeric),
+		go8583.NewFixedField(90, "originalDataElements", 42, go8583.Numeric),
+	)}
+	original := &go8583.BitmapMessage{BitmapMessageTemplate: tmpl}
+	original.Init()
+	original.SetMsgType(0x0200)
+	original.SetString(2, "4111111111111111111")
+	original.SetString(7, "1019123456")
+	original.SetString(11, "000123")
+	original.SetString(32, "00000012345")
+	original.SetString(52, "0123456789ABCDEF")
+
+	reversal, err := NewReversal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.GetMsgType() != 0x0420 {
+		t.Errorf("reversal type %04X, want 0420", reversal.GetMsgType())
+	}
+	if pan, _ := reversal.GetString(2); pan != "4111111111111111111" {
+		t.Errorf("DE2 is %s, want the original PAN", pan)
+	}
+	if reversal.IsFieldSet(7) {
+		t.Error("DE7 copied from the original")
+	}
+	if reversal.IsFieldSet(52) {
+		t.Error("PIN block copied from the original")
+	}
+	want := "0200" + "000123" + "1019123456" + "00000012345" + "00000000000"
+	if de90, _ := reversal.GetString(90); de90 != want {
+		t.Errorf("DE90 is %s, want %s", de90, want)
+	}
+}
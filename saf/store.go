@@ -0,0 +1,137 @@
+package saf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doswell/go8583/util"
+)
+
+//Entry is a queued message.
+type Entry struct {
+	ID          string    `json:"id"`
+	Data        []byte    `json:"data"` //The packed message
+	Attempts    int       `json:"attempts"`
+	Created     time.Time `json:"created"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+
+	corrupt error //Set by List for a file which cannot be decoded, whose contents are then kept in Data
+}
+
+//Store keeps queued entries. List returns entries in ID order, which is the order they were queued. An entry which
+//cannot be read back whole is still listed, so the queue can move it to the dead letter store.
+type Store interface {
+	Put(entry *Entry) error
+	Delete(id string) error
+	List() ([]*Entry, error)
+}
+
+type fileStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+//NewFileStore creates a store keeping each entry as a JSON file in dir, which is created if needed. Entries are written
+//to a temporary file and renamed, so an entry survives a crash whole or not at all.
+func NewFileStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (s *fileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *fileStore) Put(entry *Entry) error {
+	if entry.ID == "" || strings.ContainsAny(entry.ID, `/\.`) {
+		return errors.New(fmt.Sprint("Invalid entry ID ", entry.ID))
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return util.WriteFileAtomic(s.path(entry.ID), data, 0600)
+}
+
+func (s *fileStore) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *fileStore) List() ([]*Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	names, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	entries := make([]*Entry, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		entry := new(Entry)
+		if err = json.Unmarshal(data, entry); err != nil {
+			id := strings.TrimSuffix(filepath.Base(name), ".json")
+			entry = &Entry{ID: id, Data: data, corrupt: errors.New(fmt.Sprint("Corrupt SAF entry ", id, ": ", err))}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type memoryStore struct {
+	entries map[string]*Entry
+	lock    sync.Mutex
+}
+
+//NewMemoryStore creates a store which does not survive a restart, for tests and simulators.
+func NewMemoryStore() Store {
+	return &memoryStore{entries: make(map[string]*Entry)}
+}
+
+func (s *memoryStore) Put(entry *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored := *entry
+	s.entries[entry.ID] = &stored
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *memoryStore) List() ([]*Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		stored := *entry
+		entries = append(entries, &stored)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
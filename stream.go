@@ -0,0 +1,122 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+//FrameReader is implemented by packers which can read a field from a stream, using the field's length rules to know how much to read.
+type FrameReader interface {
+	//ReadFrame returns the field as it appears on the wire, including any length prefix.
+	ReadFrame(r io.Reader) (frame []byte, err error)
+}
+
+func (f *fixedField) ReadFrame(r io.Reader) (frame []byte, err error) {
+	frame = make([]byte, f.Size)
+	_, err = io.ReadFull(r, frame)
+	return frame, err
+}
+
+func (f *variableField) ReadFrame(r io.Reader) (frame []byte, err error) {
+	lengthData := make([]byte, f.varLength)
+	if _, err = io.ReadFull(r, lengthData); err != nil {
+		return nil, err
+	}
+	fieldSize, err := strconv.Atoi(string(lengthData))
+	if err != nil || fieldSize < 0 {
+		return nil, errors.New(fmt.Sprint("Invalid field length ", string(lengthData)))
+	}
+	frame = make([]byte, f.varLength+fieldSize)
+	copy(frame, lengthData)
+	_, err = io.ReadFull(r, frame[f.varLength:])
+	return frame, err
+}
+
+//ReadFrame reads the field from a stream using its packer.
+func (f *BitmapMessageField) ReadFrame(r io.Reader) (frame []byte, err error) {
+	frameReader, ok := f.PackerUnpacker.(FrameReader)
+	if !ok {
+		return nil, errors.New(fmt.Sprint("Field ", f.FieldNumber, " cannot be read from a stream"))
+	}
+	return frameReader.ReadFrame(r)
+}
+
+//Unpack reads a message from r one field at a time, without needing the size of the message in advance.
+//io.EOF is returned if r is exhausted before the message starts.
+func Unpack(r io.Reader, tmpl MessageTemplate, msg Message) (err error) {
+	if msg == nil {
+		return errors.New("Message must not be nil")
+	}
+	header := make([]byte, 12)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return err
+	}
+	msgType, err := strconv.ParseInt(string(header[0:4]), 16, 0)
+	if err != nil {
+		return errors.New(fmt.Sprint("Message type not numeric; ", err))
+	}
+	msg.SetMsgType(int(msgType))
+
+	bitmap := header[4:12]
+	if isBitmapSet(bitmap, 1) { //Extended bitmap.
+		if bitmap, err = readBitmap(r, bitmap); err != nil {
+			return err
+		}
+	}
+
+	for fieldNr := 2; fieldNr <= len(bitmap)*8; fieldNr++ {
+		if !isBitmapSet(bitmap, fieldNr) {
+			continue
+		}
+		field, err := tmpl.GetFieldDef(fieldNr)
+		if err != nil {
+			return errors.New(fmt.Sprint("Error unpacking field ", fieldNr, ": ", err))
+		}
+		frameReader, ok := field.(FrameReader)
+		if !ok {
+			return errors.New(fmt.Sprint("Field ", fieldNr, " cannot be read from a stream"))
+		}
+		frame, err := frameReader.ReadFrame(r)
+		if err != nil {
+			return noEOF(err)
+		}
+		offset, fieldValue, err := field.UnpackField(0, frame)
+		if err != nil {
+			return errors.New(fmt.Sprint("Error unpacking field ", fieldNr, ": ", err))
+		}
+		if offset != len(frame) {
+			return errors.New(fmt.Sprint("Error unpacking field ", fieldNr, ": field length does not match data read"))
+		}
+		msg.SetField(fieldNr, fieldValue)
+	}
+	return nil
+}
+
+//readBitmap reads a further 8 byte bitmap, appending it to bitmap.
+func readBitmap(r io.Reader, bitmap []byte) ([]byte, error) {
+	next := make([]byte, 8)
+	if _, err := io.ReadFull(r, next); err != nil {
+		return nil, noEOF(err)
+	}
+	return append(bitmap, next...), nil
+}
+
+//noEOF reports a stream ending part way through a message as io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+//WriteTo packs the message and writes it to w.
+func (m *BitmapMessage) WriteTo(w io.Writer) (n int64, err error) {
+	data, err := m.Pack()
+	if err != nil {
+		return 0, err
+	}
+	written, err := w.Write(data)
+	return int64(written), err
+}
@@ -0,0 +1,132 @@
+package go8583
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func streamTestTemplate() *BitmapMessageTemplate {
+	return (&BitmapMessageTemplate{Fields: CreateFields(
+		NewLlVarField(2, "pan", 19, Numeric),
+		NewFixedField(3, "processingCode", 6, Numeric),
+		NewFixedField(11, "traceNumber", 6, Numeric),
+		NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil),
+		NewEmvField(55, "iccData", 999, NewVariableFieldPackerUnpacker(3)),
+		NewLlVarField(100, "receivingInstId", 11, Numeric),
+	)}).Freeze()
+}
+
+func packedStreamMessage(t *testing.T, tmpl *BitmapMessageTemplate, stan string) *BitmapMessage {
+	t.Helper()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(2, "4012345678909")
+	msg.SetString(3, "000000")
+	msg.SetString(11, stan)
+	msg.SetSubField(48, 1, "ABCD")
+	msg.SetString(55, string(mustHex(t, "9F2608A1B2C3D4E5F60708"+"82021980")))
+	msg.SetString(100, "654321")
+	return msg
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	tmpl := streamTestTemplate()
+	stream := new(bytes.Buffer)
+	var packed [][]byte
+	for _, stan := range []string{"000001", "000002"} {
+		msg := packedStreamMessage(t, tmpl, stan)
+		data, err := msg.Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		packed = append(packed, data)
+		if n, err := msg.WriteTo(stream); err != nil || n != int64(len(data)) {
+			t.Fatalf("wrote %d bytes %v, want %d", n, err, len(data))
+		}
+	}
+
+	r := bytes.NewReader(stream.Bytes())
+	for i, stan := range []string{"000001", "000002"} {
+		msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+		msg.Init()
+		if err := Unpack(r, tmpl, msg); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if got, _ := msg.GetString(11); got != stan {
+			t.Errorf("message %d has trace number %s, want %s", i, got, stan)
+		}
+		if got, _ := msg.GetString(100); got != "654321" {
+			t.Errorf("message %d has secondary bitmap field 100 %q", i, got)
+		}
+		if got, _ := msg.GetSubField(48, 1); got != "ABCD" {
+			t.Errorf("message %d has TLV subfield 48.01 %q", i, got)
+		}
+		if got, _ := msg.GetTagString(55, 0x82); got != "1980" {
+			t.Errorf("message %d has EMV tag 82 %q", i, got)
+		}
+		repacked, err := msg.Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(repacked, packed[i]) {
+			t.Errorf("message %d repacked %q, want %q", i, repacked, packed[i])
+		}
+	}
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	if err := Unpack(r, tmpl, msg); err != io.EOF {
+		t.Errorf("reading past the last message returned %v, want io.EOF", err)
+	}
+}
+
+func TestStreamEmpty(t *testing.T) {
+	tmpl := streamTestTemplate()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	if err := Unpack(bytes.NewReader(nil), tmpl, msg); err != io.EOF {
+		t.Errorf("empty stream returned %v, want io.EOF", err)
+	}
+}
+
+func TestStreamTruncated(t *testing.T) {
+	tmpl := streamTestTemplate()
+	data, err := packedStreamMessage(t, tmpl, "000001").Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for size := 1; size < len(data); size++ {
+		msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+		msg.Init()
+		if err := Unpack(bytes.NewReader(data[:size]), tmpl, msg); err != io.ErrUnexpectedEOF {
+			t.Errorf("stream truncated to %d of %d bytes returned %v, want io.ErrUnexpectedEOF", size, len(data), err)
+		}
+	}
+}
+
+//unframedPacker is a packer which cannot read its field from a stream.
+type unframedPacker struct {
+	PackerUnpacker
+}
+
+func TestStreamFieldNotFrameReader(t *testing.T) {
+	tmpl := (&BitmapMessageTemplate{Fields: CreateFields(
+		&BitmapMessageField{3, "processingCode", Numeric, Fixed, 6, unframedPacker{NewFixedFieldPackerUnpacker(6)}, nil, nil},
+	)}).Freeze()
+	msg := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	msg.Init()
+	msg.SetMsgType(0x0200)
+	msg.SetString(3, "000000")
+	data, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked := &BitmapMessage{BitmapMessageTemplate: tmpl}
+	unpacked.Init()
+	err = Unpack(bytes.NewReader(data), tmpl, unpacked)
+	if err == nil || !strings.Contains(err.Error(), "cannot be read from a stream") {
+		t.Errorf("unpacked a field whose packer cannot read frames: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package go8583
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+//SyncMessage wraps a BitmapMessage so that several goroutines may read and enrich it at once.
+//A BitmapMessage on its own must only be used by one goroutine at a time.
+type SyncMessage struct {
+	lock sync.RWMutex
+	msg  *BitmapMessage
+}
+
+//NewSyncMessage wraps msg. msg must not be used directly while it is wrapped.
+func NewSyncMessage(msg *BitmapMessage) *SyncMessage {
+	return &SyncMessage{msg: msg}
+}
+
+//Do calls fn with exclusive access to the message, for changes that must be made together.
+func (m *SyncMessage) Do(fn func(msg *BitmapMessage)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	fn(m.msg)
+}
+
+func (m *SyncMessage) SetField(fieldNr int, value FieldValue) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.msg.SetField(fieldNr, value)
+}
+
+func (m *SyncMessage) SetString(fieldNr int, value string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.msg.SetString(fieldNr, value)
+}
+
+func (m *SyncMessage) UnsetField(fieldNr int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.msg.UnsetField(fieldNr)
+}
+
+func (m *SyncMessage) GetField(fieldNr int) (string, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.GetField(fieldNr)
+}
+
+func (m *SyncMessage) GetMsgType() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.GetMsgType()
+}
+
+func (m *SyncMessage) Pack() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.Pack()
+}
+
+func (m *SyncMessage) SetMsgType(msgType int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.msg.SetMsgType(msgType)
+}
+
+func (m *SyncMessage) CopyField(fieldNr int, msg Message) {
+	m.CopyFields(msg, fieldNr)
+}
+
+//CopyFields copies the fields, including any subfields, from src.
+func (m *SyncMessage) CopyFields(src Message, fieldNrs ...int) {
+	//Copy from the source first so copying between two wrapped messages cannot deadlock.
+	copied := &BitmapMessage{}
+	copied.Init()
+	copied.CopyFields(src, fieldNrs...)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, fieldNr := range fieldNrs {
+		if value, isSet := copied.FieldValues[fieldNr]; isSet {
+			m.msg.SetField(fieldNr, value)
+		}
+	}
+}
+
+//GetFieldValue returns a copy of the field with its subfields.
+func (m *SyncMessage) GetFieldValue(fieldNr int) (FieldValue, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, isSet := m.msg.GetFieldValue(fieldNr)
+	return value.Clone(), isSet
+}
+
+//Clone returns a deep copy of the wrapped message.
+func (m *SyncMessage) Clone() *BitmapMessage {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.Clone()
+}
+
+func (m *SyncMessage) GetSubField(fieldNr int, subFieldNr int) (string, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.GetSubField(fieldNr, subFieldNr)
+}
+
+func (m *SyncMessage) SetSubField(fieldNr, subFieldNr int, subValue string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.msg.SetSubField(fieldNr, subFieldNr, subValue)
+}
+
+func (m *SyncMessage) GetMsgTypeString() string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.GetMsgTypeString()
+}
+
+//Get returns the value at a path such as "127.22.3".
+func (m *SyncMessage) Get(path string) (string, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.Get(path)
+}
+
+//Set sets the value at a path such as "48.01".
+func (m *SyncMessage) Set(path string, value string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.msg.Set(path, value)
+}
+
+//GetTime parses a date or time field.
+func (m *SyncMessage) GetTime(fieldNr int) (time.Time, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.GetTime(fieldNr)
+}
+
+//SetTime sets a date or time field in the layout of the field.
+func (m *SyncMessage) SetTime(fieldNr int, t time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.msg.SetTime(fieldNr, t)
+}
+
+//ApplyAutoFill sets every auto filled field which is not yet set.
+func (m *SyncMessage) ApplyAutoFill() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.msg.ApplyAutoFill()
+}
+
+func (m *SyncMessage) WriteTo(w io.Writer) (int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.WriteTo(w)
+}
+
+func (m *SyncMessage) String() string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.msg.String()
+}
@@ -0,0 +1,48 @@
+package go8583
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+//freezer is implemented by composite fields, which hold a template of their own subfields.
+type freezer interface {
+	Freeze() *BitmapMessageTemplate
+}
+
+//AddField adds or replaces a field definition. Fields cannot be added once the template is frozen.
+func (t *BitmapMessageTemplate) AddField(field Field) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return errors.New(fmt.Sprint("Cannot add field ", field.GetFieldNumber(), " to a frozen template"))
+	}
+	if t.Fields == nil {
+		t.Fields = make(map[int]Field)
+	}
+	t.Fields[field.GetFieldNumber()] = field
+	return nil
+}
+
+//Freeze makes the template, and the templates of any composite fields, immutable. Field definitions can then be read
+//concurrently without locking. Freeze returns the template so it can be applied when declaring a template.
+func (t *BitmapMessageTemplate) Freeze() *BitmapMessageTemplate {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.IsFrozen() {
+		return t
+	}
+	for _, field := range t.Fields {
+		if f, ok := field.(freezer); ok {
+			f.Freeze()
+		}
+	}
+	atomic.StoreInt32(&t.frozen, 1)
+	return t
+}
+
+//IsFrozen returns true once Freeze has been called.
+func (t *BitmapMessageTemplate) IsFrozen() bool {
+	return atomic.LoadInt32(&t.frozen) == 1
+}
@@ -0,0 +1,303 @@
+package go8583
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doswell/go8583/util"
+)
+
+type lengthEncoding int
+
+const (
+	AsciiLength lengthEncoding = iota
+	BcdLength
+	BinaryLength
+)
+
+type tlvLayout int
+
+const (
+	TagLengthValue tlvLayout = iota
+	LengthTagValue
+)
+
+//TlvFormat describes the layout of private use tag-length-value subfields. These vary per network.
+type TlvFormat struct {
+	Layout            tlvLayout
+	TagSize           int //Characters in the tag
+	TagBase           int //Base the tag is read in to give the subfield number. Defaults to 10
+	LengthSize        int //Digits for ASCII lengths, otherwise bytes
+	LengthEncoding    lengthEncoding
+	LengthIncludesTag bool //The length counts the tag as well as the value
+}
+
+var (
+	//AsciiTlv2x2 is a 2 character tag followed by a 2 digit length, e.g. 0104ABCD
+	AsciiTlv2x2 = TlvFormat{TagSize: 2, LengthSize: 2}
+	//AsciiTlv2x3 is a 2 character tag followed by a 3 digit length, e.g. 01004ABCD
+	AsciiTlv2x3 = TlvFormat{TagSize: 2, LengthSize: 3}
+	//AsciiLtv3x2 is a 3 digit length followed by a 2 character tag, the length including the tag, e.g. 00601ABCD
+	AsciiLtv3x2 = TlvFormat{Layout: LengthTagValue, TagSize: 2, LengthSize: 3, LengthIncludesTag: true}
+)
+
+func (t TlvFormat) tagBase() int {
+	if t.TagBase == 0 {
+		return 10
+	}
+	return t.TagBase
+}
+
+//ParseTag converts a tag as it appears on the wire to a subfield number.
+func (t TlvFormat) ParseTag(tag string) (int, error) {
+	nr, err := strconv.ParseInt(tag, t.tagBase(), 0)
+	if err != nil {
+		return 0, errors.New(fmt.Sprint("Invalid tag ", tag))
+	}
+	return int(nr), nil
+}
+
+//FormatTag converts a subfield number to a tag as it appears on the wire.
+func (t TlvFormat) FormatTag(subFieldNr int) (string, error) {
+	tag := strings.ToUpper(strconv.FormatInt(int64(subFieldNr), t.tagBase()))
+	if len(tag) > t.TagSize {
+		return "", errors.New(fmt.Sprint("Subfield ", subFieldNr, " does not fit in tag of size ", t.TagSize))
+	}
+	return util.LeftPad2Len(tag, "0", t.TagSize), nil
+}
+
+func (t TlvFormat) readLength(offset int, data []byte) (newOffset int, length int, err error) {
+	end := offset + t.LengthSize
+	if end > len(data) {
+		return offset, 0, errors.New("Attempt to read passed end of data")
+	}
+	lengthData := data[offset:end]
+	switch t.LengthEncoding {
+	case BcdLength:
+		for _, b := range lengthData {
+			if b>>4 > 9 || b&0x0F > 9 {
+				return offset, 0, errors.New("Invalid BCD length")
+			}
+			length = length*100 + int(b>>4)*10 + int(b&0x0F)
+		}
+	case BinaryLength:
+		for _, b := range lengthData {
+			length = length<<8 | int(b)
+		}
+	default:
+		if length, err = strconv.Atoi(string(lengthData)); err != nil {
+			return offset, 0, errors.New(fmt.Sprint("Invalid length ", string(lengthData)))
+		}
+	}
+	return end, length, nil
+}
+
+func (t TlvFormat) writeLength(buf *bytes.Buffer, length int) error {
+	lengthData := make([]byte, t.LengthSize)
+	switch t.LengthEncoding {
+	case BcdLength:
+		for i := len(lengthData) - 1; i >= 0; i-- {
+			lengthData[i] = byte(length%10) | byte(length/10%10)<<4
+			length /= 100
+		}
+	case BinaryLength:
+		for i := len(lengthData) - 1; i >= 0; i-- {
+			lengthData[i] = byte(length)
+			length >>= 8
+		}
+	default:
+		s := strconv.Itoa(length)
+		if len(s) > t.LengthSize {
+			return errors.New(fmt.Sprint("Length ", length, " exceeds ", t.LengthSize, " digits"))
+		}
+		buf.WriteString(util.LeftPad2Len(s, "0", t.LengthSize))
+		return nil
+	}
+	if length != 0 {
+		return errors.New("Length exceeds length size")
+	}
+	buf.Write(lengthData)
+	return nil
+}
+
+type tlvField struct {
+	*BitmapMessageField
+	*BitmapMessageTemplate
+	Format TlvFormat
+}
+
+//NewTlvField creates a field whose subfields are private use tag-length-value data, such as DE48. Subfields need only be defined in fields to give them a name or to nest further subfields.
+func NewTlvField(fieldNumber int, name string, size int, fieldPackerUnpacker PackerUnpacker, format TlvFormat, fields []Field) Field {
+	field := &tlvField{&BitmapMessageField{fieldNumber, name, AlphaNumericSpecial, LllVar, size, fieldPackerUnpacker, nil, nil},
+		new(BitmapMessageTemplate),
+		format,
+	}
+	field.Fields = CreateFields(fields...)
+	return field
+}
+
+//GetFieldDef returns the subfield definition for the tag, or an unnamed LLVAR definition when the tag is not in the
+//template, so values of any tag can still be packed and unpacked through it.
+func (f *tlvField) GetFieldDef(subFieldNr int) (field Field, err error) {
+	if field, exists := f.Fields[subFieldNr]; exists {
+		return field, nil
+	}
+	return NewLlVarField(subFieldNr, "", 99, AlphaNumericSpecial), nil
+}
+
+func (f *tlvField) UnpackField(offset int, data []byte) (newOffset int, value FieldValue, err error) {
+	newOffset, fieldData, err := f.Unpack(offset, data)
+	if err != nil {
+		return newOffset, value, err
+	}
+	value, err = f.unpackSubFields(fieldData)
+	return newOffset, value, err
+}
+
+func (f *tlvField) PackField(value FieldValue) (data []byte, err error) {
+	subFieldData, err := f.packSubFields(value)
+	if err != nil {
+		return nil, err
+	}
+	return f.Pack(subFieldData)
+}
+
+//unpackSubFields addresses subfields by tag in FieldValues, the first of any repeated tag, and keeps every tag with its
+//data in wire order in Tlvs, as for EMV data, so repeated tags are packed back as they were received.
+func (f *tlvField) unpackSubFields(data []byte) (value FieldValue, err error) {
+	values := make(map[int]FieldValue)
+	var tlvs []BerTlv
+	offset := 0
+	for offset < len(data) {
+		var tag string
+		var length int
+		if f.Format.Layout == LengthTagValue {
+			if offset, length, err = f.Format.readLength(offset, data); err != nil {
+				return value, err
+			}
+			if offset, tag, err = f.readTag(offset, data); err != nil {
+				return value, err
+			}
+		} else {
+			if offset, tag, err = f.readTag(offset, data); err != nil {
+				return value, err
+			}
+			if offset, length, err = f.Format.readLength(offset, data); err != nil {
+				return value, err
+			}
+		}
+		if f.Format.LengthIncludesTag {
+			length -= f.Format.TagSize
+		}
+		if length < 0 || offset+length > len(data) {
+			return value, errors.New(fmt.Sprint("Invalid length for tag ", tag))
+		}
+		subFieldNr, err := f.Format.ParseTag(tag)
+		if err != nil {
+			return value, err
+		}
+		subFieldData := data[offset : offset+length]
+		offset += length
+
+		tlvs = append(tlvs, BerTlv{Tag: subFieldNr, Value: subFieldData})
+		if _, repeated := values[subFieldNr]; repeated {
+			continue
+		}
+		if composite, ok := f.Fields[subFieldNr].(compositeField); ok {
+			subValue, err := composite.unpackSubFields(subFieldData)
+			if err != nil {
+				return value, err
+			}
+			values[subFieldNr] = subValue
+		} else {
+			values[subFieldNr] = FieldValue{Value: string(subFieldData)}
+		}
+	}
+	return FieldValue{FieldValues: values, Tlvs: tlvs}, nil
+}
+
+func (f *tlvField) readTag(offset int, data []byte) (newOffset int, tag string, err error) {
+	end := offset + f.Format.TagSize
+	if end > len(data) {
+		return offset, "", errors.New("Attempt to read passed end of data")
+	}
+	return end, string(data[offset:end]), nil
+}
+
+//packSubFields packs the tags in the order they were unpacked, taking each from FieldValues so later changes are packed,
+//then tags which were not unpacked in ascending order. Repeated tags are packed as they were unpacked, and tags removed
+//from FieldValues are dropped.
+func (f *tlvField) packSubFields(value FieldValue) (data []byte, err error) {
+	values := value.FieldValues
+	buf := new(bytes.Buffer)
+
+	packed := make(map[int]bool, len(values))
+	for _, tlv := range value.Tlvs {
+		subValue, ok := values[tlv.Tag]
+		if !ok {
+			continue
+		}
+		if packed[tlv.Tag] {
+			err = f.writeSubField(buf, tlv.Tag, tlv.Value)
+		} else {
+			packed[tlv.Tag] = true
+			err = f.packSubField(buf, tlv.Tag, subValue)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tags []int
+	for tag := range values {
+		if !packed[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Ints(tags)
+
+	for _, subFieldNr := range tags {
+		if err = f.packSubField(buf, subFieldNr, values[subFieldNr]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *tlvField) packSubField(buf *bytes.Buffer, subFieldNr int, value FieldValue) (err error) {
+	subFieldData := []byte(value.Value)
+	if composite, ok := f.Fields[subFieldNr].(compositeField); ok && value.FieldValues != nil {
+		if subFieldData, err = composite.packSubFields(value); err != nil {
+			return err
+		}
+	}
+	return f.writeSubField(buf, subFieldNr, subFieldData)
+}
+
+func (f *tlvField) writeSubField(buf *bytes.Buffer, subFieldNr int, subFieldData []byte) error {
+	tag, err := f.Format.FormatTag(subFieldNr)
+	if err != nil {
+		return err
+	}
+	length := len(subFieldData)
+	if f.Format.LengthIncludesTag {
+		length += f.Format.TagSize
+	}
+	if f.Format.Layout == LengthTagValue {
+		if err = f.Format.writeLength(buf, length); err != nil {
+			return err
+		}
+		buf.WriteString(tag)
+	} else {
+		buf.WriteString(tag)
+		if err = f.Format.writeLength(buf, length); err != nil {
+			return err
+		}
+	}
+	buf.Write(subFieldData)
+	return nil
+}
@@ -0,0 +1,117 @@
+package go8583
+
+import (
+	"testing"
+)
+
+func TestTlvFieldRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		format TlvFormat
+		data   string
+		values map[int]string
+	}{
+		{"ascii 2x2", AsciiTlv2x2, "0104ABCD0203XYZ", map[int]string{1: "ABCD", 2: "XYZ"}},
+		{"ascii 2x3", AsciiTlv2x3, "01004ABCD", map[int]string{1: "ABCD"}},
+		{"ascii ltv 3x2", AsciiLtv3x2, "00601ABCD00302Z", map[int]string{1: "ABCD", 2: "Z"}},
+		{"hex tags", TlvFormat{TagSize: 2, TagBase: 16, LengthSize: 2}, "1F02OK", map[int]string{0x1F: "OK"}},
+		{"binary length", TlvFormat{TagSize: 2, LengthSize: 1, LengthEncoding: BinaryLength}, "01\x03ABC", map[int]string{1: "ABC"}},
+		{"bcd length", TlvFormat{TagSize: 2, LengthSize: 2, LengthEncoding: BcdLength}, "01\x00\x03ABC", map[int]string{1: "ABC"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), test.format, nil).(*tlvField)
+			value, err := field.unpackSubFields([]byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(value.FieldValues) != len(test.values) {
+				t.Fatalf("got %d subfields, want %d", len(value.FieldValues), len(test.values))
+			}
+			for subFieldNr, want := range test.values {
+				if got := value.FieldValues[subFieldNr].Value; got != want {
+					t.Errorf("subfield %d is %q, want %q", subFieldNr, got, want)
+				}
+			}
+			data, err := field.packSubFields(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.data {
+				t.Errorf("packed %q, want %q", data, test.data)
+			}
+		})
+	}
+}
+
+func TestTlvFieldUnknownTagDefinition(t *testing.T) {
+	field := NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil).(*tlvField)
+	def, err := field.GetFieldDef(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := def.PackField(FieldValue{Value: "ABC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, value, err := def.UnpackField(0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Value != "ABC" {
+		t.Errorf("unpacked %q, want ABC", value.Value)
+	}
+}
+
+func TestTlvFieldInvalid(t *testing.T) {
+	field := NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil).(*tlvField)
+	for _, data := range []string{"0", "0109AB", "01XXAB", "ZZ02AB"} {
+		if _, err := field.unpackSubFields([]byte(data)); err == nil {
+			t.Errorf("%q unpacked without error", data)
+		}
+	}
+}
+
+func TestTlvFieldRepeatedTagsAndOrder(t *testing.T) {
+	field := NewTlvField(48, "additionalData", 999, NewVariableFieldPackerUnpacker(3), AsciiTlv2x2, nil).(*tlvField)
+	tests := []struct {
+		name   string
+		data   string
+		edit   func(values map[int]FieldValue)
+		packed string
+	}{
+		{"repeated tag", "0102AB0102CD", nil, "0102AB0102CD"},
+		{"wire order", "0203XYZ0104ABCD", nil, "0203XYZ0104ABCD"},
+		{"edit first occurrence", "0102AB0302EF0102CD", func(values map[int]FieldValue) {
+			values[1] = FieldValue{Value: "GH"}
+		}, "0102GH0302EF0102CD"},
+		{"added tag", "0302EF0102AB", func(values map[int]FieldValue) {
+			values[2] = FieldValue{Value: "Z"}
+		}, "0302EF0102AB0201Z"},
+		{"removed tag", "0102AB0302EF0102CD", func(values map[int]FieldValue) {
+			delete(values, 1)
+		}, "0302EF"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := field.unpackSubFields([]byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.edit != nil {
+				test.edit(value.FieldValues)
+			}
+			data, err := field.packSubFields(value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.packed {
+				t.Errorf("packed %q, want %q", data, test.packed)
+			}
+		})
+	}
+	value, _ := field.unpackSubFields([]byte("0102AB0102CD"))
+	if got := value.FieldValues[1].Value; got != "AB" {
+		t.Errorf("repeated tag addresses %q, want the first occurrence AB", got)
+	}
+}
@@ -1,22 +1,46 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
 func RightPad2Len(s string, padStr string, overallLen int) string {
-	var padCountInt int
-	padCountInt = 1 + ((overallLen - len(padStr)) / len(padStr))
-	var retStr = s + strings.Repeat(padStr, padCountInt)
-	return retStr[:overallLen]
+	if len(padStr) != 1 {
+		var padCountInt int
+		padCountInt = 1 + ((overallLen - len(padStr)) / len(padStr))
+		var retStr = s + strings.Repeat(padStr, padCountInt)
+		return retStr[:overallLen]
+	}
+	if len(s) >= overallLen {
+		return s[:overallLen]
+	}
+	buf := make([]byte, overallLen)
+	n := copy(buf, s)
+	for i := n; i < overallLen; i++ {
+		buf[i] = padStr[0]
+	}
+	return string(buf)
 }
 func LeftPad2Len(s string, padStr string, overallLen int) string {
-	var padCountInt int
-	padCountInt = 1 + ((overallLen - len(padStr)) / len(padStr))
-	var retStr = strings.Repeat(padStr, padCountInt) + s
-	return retStr[(len(retStr) - overallLen):]
+	if len(padStr) != 1 {
+		var padCountInt int
+		padCountInt = 1 + ((overallLen - len(padStr)) / len(padStr))
+		var retStr = strings.Repeat(padStr, padCountInt) + s
+		return retStr[(len(retStr) - overallLen):]
+	}
+	if len(s) >= overallLen {
+		return s[len(s)-overallLen:]
+	}
+	buf := make([]byte, overallLen)
+	padLen := overallLen - len(s)
+	for i := 0; i < padLen; i++ {
+		buf[i] = padStr[0]
+	}
+	copy(buf[padLen:], s)
+	return string(buf)
 }
 
 // Reversing bits in a word, refined basic scheme.
@@ -62,14 +86,15 @@ func GetBitmap(data []byte) []bool {
 }
 
 func Spacify(str string) string {
-	var newStr string
+	var newStr strings.Builder
+	newStr.Grow(len(str) + len(str)/2)
 	for i, s := range str {
 		if i > 0 && i%2 == 0 {
-			newStr += " "
+			newStr.WriteByte(' ')
 		}
-		newStr += string(s)
+		newStr.WriteRune(s)
 	}
-	return newStr
+	return newStr.String()
 }
 
 func Split2(s, sep string) (string, string) {
@@ -87,13 +112,14 @@ func Split2(s, sep string) (string, string) {
 	return array[0], array[1]
 }
 
-func CheckDigit(number string, base int) (digit int) {
+//CheckDigit returns the Luhn check digit of number in base, such as 10 for card numbers. An error is returned if number
+//has a character which is not a digit in base.
+func CheckDigit(number string, base int) (digit int, err error) {
 	digits := make([]int64, (len(number)))
-	var err error
 	for i := 0; i < len(number); i++ {
 		digits[i], err = strconv.ParseInt(string(number[i]), base, 64)
 		if err != nil {
-			fmt.Println(err)
+			return 0, errors.New(fmt.Sprint("Invalid base ", base, " digit ", string(number[i]), " in ", number))
 		}
 	}
 	sum := int64(0)
@@ -108,9 +134,25 @@ func CheckDigit(number string, base int) (digit int) {
 	if base == digit {
 		digit = 0
 	}
-	return
+	return digit, nil
 }
 
-func Checksummed(number string, base int) (check string) {
-	return fmt.Sprintf("%s%d", number, CheckDigit(number, base))
+//Checksummed returns number followed by its check digit in base.
+func Checksummed(number string, base int) (check string, err error) {
+	digit, err := CheckDigit(number, base)
+	if err != nil {
+		return "", err
+	}
+	return number + strconv.FormatInt(int64(digit), base), nil
+}
+
+//IsDigits returns true if every character of s is an ASCII decimal digit. An empty string has no other characters, so
+//callers needing a value check its length as well.
+func IsDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
 }
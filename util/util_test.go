@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		number string
+		base   int
+		digit  int
+	}{
+		{"7992739871", 10, 3},
+		{"401234567890", 10, 9},
+		{"", 10, 0},
+	}
+	for _, test := range tests {
+		if digit, err := CheckDigit(test.number, test.base); err != nil || digit != test.digit {
+			t.Errorf("check digit of %s is %d %v, want %d", test.number, digit, err, test.digit)
+		}
+	}
+	if _, err := CheckDigit("40123X", 10); err == nil {
+		t.Error("computed the check digit of a non digit without error")
+	}
+	if check, err := Checksummed("7992739871", 10); err != nil || check != "79927398713" {
+		t.Errorf("checksummed %s %v, want 79927398713", check, err)
+	}
+}
+
+func TestIsDigits(t *testing.T) {
+	for s, want := range map[string]bool{"0123456789": true, "": true, "12a": false, " 1": false, "١": false} {
+		if got := IsDigits(s); got != want {
+			t.Errorf("IsDigits(%q) is %v, want %v", s, got, want)
+		}
+	}
+}
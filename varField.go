@@ -2,9 +2,8 @@ package go8583
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
-
-	"github.com/doswell/go8583/util"
 )
 
 type variableField struct {
@@ -36,44 +35,59 @@ func (f *variableField) Pack(fieldData []byte) (data []byte, err error) {
 	//		return nil, err
 	//	}
 
-	msgData := []byte(util.LeftPad2Len(strconv.Itoa(len(fieldData)), "0", f.varLength))
-	msgData = append(msgData, fieldData...)
-	return msgData, nil
+	return f.Append(make([]byte, 0, f.varLength+len(fieldData)), string(fieldData))
+}
+
+//Append writes the length as ASCII digits followed by the field data.
+func (f *variableField) Append(dst []byte, fieldData string) ([]byte, error) {
+	length := len(fieldData)
+	start := len(dst)
+	for i := 0; i < f.varLength; i++ {
+		dst = append(dst, '0')
+	}
+	for i := len(dst) - 1; i >= start && length > 0; i-- {
+		dst[i] = byte('0' + length%10)
+		length /= 10
+	}
+	if length > 0 {
+		return dst[:start], errors.New(fmt.Sprint("Field data length ", len(fieldData), " exceeds ", f.varLength, " length digits"))
+	}
+	return append(dst, fieldData...), nil
 }
 
 //NewLVarField creates a new variable length field denoted by a length of one byte. Valid lengths are 0-9
 func NewLVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LVar, size, NewVariableFieldPackerUnpacker(1), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LVar, size, NewVariableFieldPackerUnpacker(1), nil, nil}
 }
 
 //NewLlVarField creates a new variable length field denoted by a length of two bytes. Valid lengths are 00-99
 func NewLlVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(2), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(2), nil, nil}
 }
 
 //NewLllVarField creates a new variable length field denoted by a length of three bytes. Valid lengths are 000-999
 func NewLllVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(3), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(3), nil, nil}
 }
 
 //NewLlllVarField creates a new variable length field denoted by a length of four bytes. Valid lengths are 0000-9999
 func NewLlllVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(4), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(4), nil, nil}
 }
 
 //NewLllllVarField creates a new variable length field denoted by a length of five bytes. Valid lengths are 00000-99999
 func NewLllllVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(5), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(5), nil, nil}
 }
 
 //NewLlllllVarField creates a new variable length field denoted by a length of six bytes. Valid lengths are 000000-999999
 func NewLlllllVarField(bitNumber int, name string, size int, fieldType fieldType) Field {
-	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(6), nil}
+	return &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(6), nil, nil}
 }
 
 //NewLllllVarFieldWithCustomUnpacker creates a new variable length field denoted by a length of five bytes. Valid lengths are 000000-999999, and using a custom unpacker.
 func NewLllllVarFieldWithCustomUnpacker(bitNumber int, name string, size int, fieldType fieldType, fieldPackerUnpacker FieldPackerUnpacker) Field {
-	f := &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(6), fieldPackerUnpacker}
+	f := &BitmapMessageField{bitNumber, name, fieldType, LlVar, size, NewVariableFieldPackerUnpacker(6), fieldPackerUnpacker, nil}
 	f.FieldPackerUnpacker = fieldPackerUnpacker
 	return f
 }